@@ -3,7 +3,13 @@ package main
 import (
 	"backend-evermos/internal/helper"
 	"backend-evermos/internal/infrastructure/container"
+	"backend-evermos/internal/pkg/model"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"time"
 
 	rest "backend-evermos/internal/server/http"
 
@@ -16,8 +22,21 @@ func main() {
 	containerConf := container.InitContainer()
 	// defer mysql.CloseDatabaseConnection(containerConf.Mysqldb)
 
+	if len(os.Args) > 1 {
+		runCommand(containerConf, os.Args[1], os.Args[2:])
+		return
+	}
+
+	if containerConf.Uploads.ReconcileInterval > 0 {
+		go scheduleUploadReconciliation(containerConf)
+	}
+	if containerConf.Alerts.EmailInterval > 0 {
+		go scheduleStockAlertEmails(containerConf)
+	}
+
 	app := fiber.New(fiber.Config{
-		BodyLimit: 10 * 1024 * 1024,
+		BodyLimit:   10 * 1024 * 1024,
+		ProxyHeader: containerConf.Apps.ProxyHeader,
 	})
 
 	app.Use(recover.New())
@@ -30,3 +49,109 @@ func main() {
 		helper.Logger(helper.LoggerLevelFatal, "error", err)
 	}
 }
+
+// runCommand executes one-off maintenance commands, e.g. `go run app/main.go reindex`.
+func runCommand(containerConf *container.Container, command string, args []string) {
+	ctx := context.Background()
+
+	switch command {
+	case "reindex":
+		total, err := containerConf.ProductsUsc.ReindexProducts(ctx)
+		if err != nil {
+			helper.Logger(helper.LoggerLevelFatal, "reindex failed", err.Err)
+		}
+		helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Reindexed %d products", total), nil)
+	case "reconcile-uploads":
+		flags := flag.NewFlagSet(command, flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "only report orphaned and missing files")
+		grace := flags.Duration("grace", containerConf.Uploads.GracePeriod, "keep orphaned files younger than this")
+		_ = flags.Parse(args)
+
+		reconcileUploads(ctx, containerConf, model.ReconcileUploadsReq{
+			DryRun:      *dryRun,
+			GracePeriod: *grace,
+		})
+	case "backfill-renditions":
+		flags := flag.NewFlagSet(command, flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "only report images missing renditions")
+		_ = flags.Parse(args)
+
+		res, err := containerConf.UploadsUsc.BackfillRenditions(ctx, model.BackfillRenditionsReq{DryRun: *dryRun})
+		if err != nil {
+			helper.Logger(helper.LoggerLevelFatal, "backfill renditions failed", err.Err)
+		}
+
+		for _, key := range res.Failed {
+			helper.Logger(helper.LoggerLevelInfo, "Failed to generate renditions: "+key, nil)
+		}
+		helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Checked %d images: %d missing renditions, %d generated, %d failed",
+			res.Checked, len(res.Missing), len(res.Generated), len(res.Failed)), nil)
+	case "send-stock-alerts":
+		sendStockAlertEmails(ctx, containerConf)
+	case "grant-role":
+		if len(args) != 2 {
+			helper.Logger(helper.LoggerLevelFatal, "usage: grant-role <no_telp> <role>", errors.New("invalid arguments"))
+		}
+
+		if err := containerConf.RolesUsc.GrantRoleByPhoneNumber(ctx, args[0], args[1]); err != nil {
+			helper.Logger(helper.LoggerLevelFatal, "grant role failed", err.Err)
+		}
+		helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Granted role %s to %s", args[1], args[0]), nil)
+	default:
+		helper.Logger(helper.LoggerLevelFatal, "unknown command", errors.New("unknown command: "+command))
+	}
+}
+
+func reconcileUploads(ctx context.Context, containerConf *container.Container, params model.ReconcileUploadsReq) {
+	res, err := containerConf.UploadsUsc.ReconcileUploads(ctx, params)
+	if err != nil {
+		helper.Logger(helper.LoggerLevelError, "reconcile uploads failed", err.Err)
+		return
+	}
+
+	for _, key := range res.Missing {
+		helper.Logger(helper.LoggerLevelInfo, "Missing upload: "+key, nil)
+	}
+	for _, key := range res.MissingRenditions {
+		helper.Logger(helper.LoggerLevelInfo, "Missing renditions, run backfill-renditions: "+key, nil)
+	}
+	for _, key := range res.Orphans {
+		helper.Logger(helper.LoggerLevelInfo, "Orphaned upload: "+key, nil)
+	}
+
+	helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Checked %d uploads: %d orphaned, %d missing, %d missing renditions, %d deleted",
+		res.Checked, len(res.Orphans), len(res.Missing), len(res.MissingRenditions), len(res.Deleted)), nil)
+}
+
+func scheduleUploadReconciliation(containerConf *container.Container) {
+	ticker := time.NewTicker(containerConf.Uploads.ReconcileInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		reconcileUploads(context.Background(), containerConf, model.ReconcileUploadsReq{
+			DryRun:      containerConf.Uploads.DryRun,
+			GracePeriod: containerConf.Uploads.GracePeriod,
+		})
+	}
+}
+
+func sendStockAlertEmails(ctx context.Context, containerConf *container.Container) {
+	total, err := containerConf.StockAlertsUsc.DispatchStockAlertEmails(ctx)
+	if err != nil {
+		helper.Logger(helper.LoggerLevelError, "send stock alerts failed", err.Err)
+		return
+	}
+
+	if total > 0 {
+		helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Emailed %d stock alerts", total), nil)
+	}
+}
+
+func scheduleStockAlertEmails(containerConf *container.Container) {
+	ticker := time.NewTicker(containerConf.Alerts.EmailInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sendStockAlertEmails(context.Background(), containerConf)
+	}
+}
@@ -9,6 +9,7 @@ require (
 	github.com/rs/zerolog v1.34.0
 	github.com/spf13/viper v1.20.1
 	golang.org/x/crypto v0.37.0
+	golang.org/x/image v0.24.0
 	gorm.io/driver/mysql v1.5.7
 	gorm.io/gorm v1.26.0
 )
@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthUser is the caller identity the auth middleware stores in the request
+// context once a token has been verified.
+type AuthUser struct {
+	ID          string
+	Email       string
+	Roles       []string
+	Permissions []string
+}
+
+type authUserKey struct{}
+
+func SetAuthUser(ctx *fiber.Ctx, user AuthUser) {
+	ctx.Locals(authUserKey{}, user)
+}
+
+// GetAuthUser returns the authenticated caller, ok is false for anonymous
+// requests.
+func GetAuthUser(ctx *fiber.Ctx) (user AuthUser, ok bool) {
+	user, ok = ctx.Locals(authUserKey{}).(AuthUser)
+	return user, ok
+}
+
+// AuthUserID returns the id of the authenticated caller or an empty string
+// for anonymous requests.
+func AuthUserID(ctx *fiber.Ctx) string {
+	user, _ := GetAuthUser(ctx)
+	return user.ID
+}
+
+func (u AuthUser) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (u AuthUser) HasPermission(permission string) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasPermission reports whether the authenticated caller may do permission,
+// anonymous callers may do nothing.
+func HasPermission(ctx *fiber.Ctx, permission string) bool {
+	user, _ := GetAuthUser(ctx)
+	return user.HasPermission(permission)
+}
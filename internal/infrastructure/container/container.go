@@ -2,8 +2,11 @@ package container
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/mailer"
 	"backend-evermos/internal/infrastructure/mysql"
+	"backend-evermos/internal/infrastructure/otp"
 	"backend-evermos/internal/infrastructure/restclient"
+	"backend-evermos/internal/infrastructure/storage"
 	"backend-evermos/internal/pkg/repository"
 	"backend-evermos/internal/pkg/usecase"
 	"backend-evermos/internal/utils"
@@ -11,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"time"
 
 	"github.com/spf13/viper"
 	"gorm.io/gorm"
@@ -20,15 +24,24 @@ var v *viper.Viper
 
 type (
 	Container struct {
-		Mysqldb       *gorm.DB
-		Apps          *Apps
-		AuthUsc       usecase.AuthUseCase
-		UsersUsc      usecase.UsersUseCase
-		ShopsUsc      usecase.ShopsUseCase
-		ProductsUsc   usecase.ProductsUseCase
-		CategoriesUsc usecase.CategoriesUseCase
-		TrxUsc        usecase.TrxUseCase
-		ProvcityUsc   usecase.ProvcityUseCase
+		Mysqldb        *gorm.DB
+		Apps           *Apps
+		AuthUsc        usecase.AuthUseCase
+		UsersUsc       usecase.UsersUseCase
+		ShopsUsc       usecase.ShopsUseCase
+		ProductsUsc    usecase.ProductsUseCase
+		CategoriesUsc  usecase.CategoriesUseCase
+		TrxUsc         usecase.TrxUseCase
+		ProvcityUsc    usecase.ProvcityUseCase
+		WishlistsUsc   usecase.WishlistsUseCase
+		ShopFollowsUsc usecase.ShopFollowsUseCase
+		ModerationUsc  usecase.ModerationUseCase
+		RolesUsc       usecase.RolesUseCase
+		UploadsUsc     usecase.UploadsUseCase
+		StockAlertsUsc usecase.StockAlertsUseCase
+		Storage        storage.Storage
+		Uploads        *UploadsConf
+		Alerts         *AlertsConf
 	}
 
 	Apps struct {
@@ -38,6 +51,101 @@ type (
 		Address   string `mapstructure:"address"`
 		HttpPort  int    `mapstructure:"httpport"`
 		SecretJwt string `mapstructure:"secretJwt"`
+
+		PublicBaseURL string `mapstructure:"publicBaseUrl"`
+		// ProxyHeader holds the client IP when running behind a proxy,
+		// e.g. X-Forwarded-For.
+		ProxyHeader string `mapstructure:"proxyHeader"`
+	}
+
+	SearchConf struct {
+		Driver string `mapstructure:"search_driver"`
+		Host   string `mapstructure:"search_host"`
+		APIKey string `mapstructure:"search_apiKey"`
+		Index  string `mapstructure:"search_index"`
+	}
+
+	ProductConf struct {
+		MinPhotos      int   `mapstructure:"product_minPhotos"`
+		MaxPhotos      int   `mapstructure:"product_maxPhotos"`
+		MaxPhotoSizeKB int64 `mapstructure:"product_maxPhotoSizeKB"`
+	}
+
+	StorageConf struct {
+		Driver      string `mapstructure:"storage_driver"`
+		LocalRoot   string `mapstructure:"storage_localRoot"`
+		S3Endpoint  string `mapstructure:"storage_s3Endpoint"`
+		S3Region    string `mapstructure:"storage_s3Region"`
+		S3Bucket    string `mapstructure:"storage_s3Bucket"`
+		S3AccessKey string `mapstructure:"storage_s3AccessKey"`
+		S3SecretKey string `mapstructure:"storage_s3SecretKey"`
+		S3PublicURL string `mapstructure:"storage_s3PublicUrl"`
+		S3PathStyle bool   `mapstructure:"storage_s3PathStyle"`
+	}
+
+	UploadsConf struct {
+		// ReconcileInterval schedules the orphaned upload reconciliation,
+		// zero disables it.
+		ReconcileInterval time.Duration `mapstructure:"uploads_reconcileInterval"`
+		GracePeriod       time.Duration `mapstructure:"uploads_gracePeriod"`
+		DryRun            bool          `mapstructure:"uploads_reconcileDryRun"`
+	}
+
+	ShopConf struct {
+		MaxPhotoSizeKB int64 `mapstructure:"shop_maxPhotoSizeKB"`
+		// RenameInterval is how long a shop has to wait between changes
+		// of its name or handle.
+		RenameInterval time.Duration `mapstructure:"shop_renameInterval"`
+	}
+
+	MailConf struct {
+		Driver       string `mapstructure:"mail_driver"`
+		SMTPHost     string `mapstructure:"mail_smtpHost"`
+		SMTPPort     int    `mapstructure:"mail_smtpPort"`
+		SMTPUsername string `mapstructure:"mail_smtpUsername"`
+		SMTPPassword string `mapstructure:"mail_smtpPassword"`
+		From         string `mapstructure:"mail_from"`
+	}
+
+	AuthConf struct {
+		AccessTokenTTL  time.Duration `mapstructure:"auth_accessTokenTTL"`
+		RefreshTokenTTL time.Duration `mapstructure:"auth_refreshTokenTTL"`
+	}
+
+	OTPConf struct {
+		Driver      string `mapstructure:"otp_driver"`
+		FilePath    string `mapstructure:"otp_filePath"`
+		HTTPURL     string `mapstructure:"otp_httpUrl"`
+		HTTPAPIKey  string `mapstructure:"otp_httpApiKey"`
+		HTTPChannel string `mapstructure:"otp_httpChannel"`
+
+		TTL            time.Duration `mapstructure:"otp_ttl"`
+		MaxAttempts    int           `mapstructure:"otp_maxAttempts"`
+		ResendInterval time.Duration `mapstructure:"otp_resendInterval"`
+		DailyCodes     int           `mapstructure:"otp_dailyCodes"`
+		DailyAttempts  int           `mapstructure:"otp_dailyAttempts"`
+	}
+
+	EmailVerificationConf struct {
+		LinkTTL            time.Duration `mapstructure:"emailVerification_linkTTL"`
+		ResendInterval     time.Duration `mapstructure:"emailVerification_resendInterval"`
+		RequireForCheckout bool          `mapstructure:"emailVerification_requireForCheckout"`
+		RequireForSelling  bool          `mapstructure:"emailVerification_requireForSelling"`
+	}
+
+	LoginGuardConf struct {
+		Driver             string        `mapstructure:"loginGuard_driver"`
+		MaxAccountFailures int           `mapstructure:"loginGuard_maxAccountFailures"`
+		MaxIPFailures      int           `mapstructure:"loginGuard_maxIPFailures"`
+		Window             time.Duration `mapstructure:"loginGuard_window"`
+		Lockout            time.Duration `mapstructure:"loginGuard_lockout"`
+		MaxLockout         time.Duration `mapstructure:"loginGuard_maxLockout"`
+	}
+
+	AlertsConf struct {
+		// EmailInterval schedules emailing stock alerts to shop owners,
+		// zero disables it.
+		EmailInterval time.Duration `mapstructure:"alerts_emailInterval"`
 	}
 )
 
@@ -85,11 +193,225 @@ func AppsInit(v *viper.Viper) (apps Apps) {
 	return
 }
 
+func SearchIndexInit(v *viper.Viper, db *gorm.DB, client *restclient.RestClient) repository.ProductSearchIndex {
+	var conf SearchConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal search configuration : ", err.Error()), err)
+	}
+
+	switch conf.Driver {
+	case "meilisearch":
+		helper.Logger(helper.LoggerLevelInfo, "Using meilisearch product search index", nil)
+		return repository.NewMeilisearchProductIndex(client, conf.Host, conf.APIKey, conf.Index)
+	default:
+		return repository.NewMysqlProductSearchIndex(db)
+	}
+}
+
+func StorageInit(v *viper.Viper, apps Apps) storage.Storage {
+	v.SetDefault("storage_localRoot", "files")
+
+	var conf StorageConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal storage configuration : ", err.Error()), err)
+	}
+
+	switch conf.Driver {
+	case "s3":
+		helper.Logger(helper.LoggerLevelInfo, "Using s3 file storage", nil)
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:  conf.S3Endpoint,
+			Region:    conf.S3Region,
+			Bucket:    conf.S3Bucket,
+			AccessKey: conf.S3AccessKey,
+			SecretKey: conf.S3SecretKey,
+			PublicURL: conf.S3PublicURL,
+			PathStyle: conf.S3PathStyle,
+		})
+	default:
+		return storage.NewLocalStorage(conf.LocalRoot, apps.PublicBaseURL)
+	}
+}
+
+func ProductPhotoLimitsInit(v *viper.Viper) usecase.ProductPhotoLimits {
+	v.SetDefault("product_minPhotos", 1)
+	v.SetDefault("product_maxPhotos", 8)
+	v.SetDefault("product_maxPhotoSizeKB", 2048)
+
+	var conf ProductConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal product configuration : ", err.Error()), err)
+	}
+
+	return usecase.ProductPhotoLimits{
+		Min:     conf.MinPhotos,
+		Max:     conf.MaxPhotos,
+		MaxSize: conf.MaxPhotoSizeKB * 1024,
+	}
+}
+
+func ShopInit(v *viper.Viper) (conf ShopConf) {
+	v.SetDefault("shop_maxPhotoSizeKB", 1024)
+	v.SetDefault("shop_renameInterval", "720h")
+
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal shop configuration : ", err.Error()), err)
+	}
+
+	return conf
+}
+
+func UploadsInit(v *viper.Viper) (conf UploadsConf) {
+	v.SetDefault("uploads_gracePeriod", "24h")
+
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal uploads configuration : ", err.Error()), err)
+	}
+
+	return conf
+}
+
+func MailerInit(v *viper.Viper) mailer.Mailer {
+	v.SetDefault("mail_smtpPort", 587)
+
+	var conf MailConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal mail configuration : ", err.Error()), err)
+	}
+
+	switch conf.Driver {
+	case "smtp":
+		helper.Logger(helper.LoggerLevelInfo, "Using smtp mailer", nil)
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     conf.SMTPHost,
+			Port:     conf.SMTPPort,
+			Username: conf.SMTPUsername,
+			Password: conf.SMTPPassword,
+			From:     conf.From,
+		})
+	default:
+		return mailer.NewLogMailer()
+	}
+}
+
+func AuthInit(v *viper.Viper) usecase.AuthTokenTTL {
+	v.SetDefault("auth_accessTokenTTL", "15m")
+	v.SetDefault("auth_refreshTokenTTL", "720h")
+
+	var conf AuthConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal auth configuration : ", err.Error()), err)
+	}
+
+	return usecase.AuthTokenTTL{
+		Access:  conf.AccessTokenTTL,
+		Refresh: conf.RefreshTokenTTL,
+	}
+}
+
+func OTPInit(v *viper.Viper, client *restclient.RestClient) (otp.OTPSender, usecase.PasswordResetConf) {
+	v.SetDefault("otp_httpChannel", "sms")
+	v.SetDefault("otp_ttl", "10m")
+	v.SetDefault("otp_maxAttempts", 5)
+	v.SetDefault("otp_resendInterval", "1m")
+	v.SetDefault("otp_dailyCodes", 5)
+	v.SetDefault("otp_dailyAttempts", 10)
+
+	var conf OTPConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal otp configuration : ", err.Error()), err)
+	}
+
+	passwordReset := usecase.PasswordResetConf{
+		OTPTTL:         conf.TTL,
+		MaxAttempts:    conf.MaxAttempts,
+		ResendInterval: conf.ResendInterval,
+		DailyCodes:     conf.DailyCodes,
+		DailyAttempts:  conf.DailyAttempts,
+	}
+
+	switch conf.Driver {
+	case "http":
+		helper.Logger(helper.LoggerLevelInfo, "Using http otp sender", nil)
+		return otp.NewHTTPSender(client, otp.HTTPConfig{
+			URL:     conf.HTTPURL,
+			APIKey:  conf.HTTPAPIKey,
+			Channel: conf.HTTPChannel,
+		}), passwordReset
+	default:
+		return otp.NewLogSender(conf.FilePath), passwordReset
+	}
+}
+
+func EmailVerificationInit(v *viper.Viper, apps Apps) usecase.EmailVerificationConf {
+	v.SetDefault("emailVerification_linkTTL", "72h")
+	v.SetDefault("emailVerification_resendInterval", "5m")
+
+	var conf EmailVerificationConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal email verification configuration : ", err.Error()), err)
+	}
+
+	return usecase.EmailVerificationConf{
+		LinkBaseURL:        apps.PublicBaseURL,
+		LinkTTL:            conf.LinkTTL,
+		ResendInterval:     conf.ResendInterval,
+		RequireForCheckout: conf.RequireForCheckout,
+		RequireForSelling:  conf.RequireForSelling,
+	}
+}
+
+func LoginGuardInit(v *viper.Viper, db *gorm.DB) (repository.LoginAttemptStore, usecase.LoginGuardConf) {
+	v.SetDefault("loginGuard_maxAccountFailures", 5)
+	v.SetDefault("loginGuard_maxIPFailures", 20)
+	v.SetDefault("loginGuard_window", "1h")
+	v.SetDefault("loginGuard_lockout", "1m")
+	v.SetDefault("loginGuard_maxLockout", "30m")
+
+	var conf LoginGuardConf
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal login guard configuration : ", err.Error()), err)
+	}
+
+	guard := usecase.LoginGuardConf{
+		MaxAccountFailures: conf.MaxAccountFailures,
+		MaxIPFailures:      conf.MaxIPFailures,
+		Window:             conf.Window,
+		Lockout:            conf.Lockout,
+		MaxLockout:         conf.MaxLockout,
+	}
+
+	switch conf.Driver {
+	case "mysql":
+		helper.Logger(helper.LoggerLevelInfo, "Using mysql login attempt store", nil)
+		return repository.NewMysqlLoginAttemptStore(db), guard
+	default:
+		return repository.NewMemoryLoginAttemptStore(), guard
+	}
+}
+
+func AlertsInit(v *viper.Viper) (conf AlertsConf) {
+	v.SetDefault("alerts_emailInterval", "0s")
+
+	if err := v.Unmarshal(&conf); err != nil {
+		helper.Logger(helper.LoggerLevelPanic, fmt.Sprint("Error when unmarshal alerts configuration : ", err.Error()), err)
+	}
+
+	return conf
+}
+
 func InitContainer() (cont *Container) {
 	apps := AppsInit(v)
 	utils.InitJWT(apps.SecretJwt)
 	mysqldb := mysql.DatabaseInit(v)
 	restClient := restclient.New()
+	fileStorage := StorageInit(v, apps)
+	uploads := UploadsInit(v)
+	alerts := AlertsInit(v)
+	shop := ShopInit(v)
+	mail := MailerInit(v)
+	otpSender, passwordReset := OTPInit(v, restClient)
+	loginAttemptStore, loginGuard := LoginGuardInit(v, mysqldb)
 
 	userRepo := repository.NewUsersRepository(mysqldb)
 	shopRepo := repository.NewShopsRepository(mysqldb)
@@ -101,24 +423,52 @@ func InitContainer() (cont *Container) {
 	trxDetailRepo := repository.NewTrxDetailsRepository(mysqldb)
 	productLogRepo := repository.NewProductLogsRepository(mysqldb)
 	provcityRepo := repository.NewProvcityRepository(restClient)
+	wishlistRepo := repository.NewWishlistsRepository(mysqldb)
+	shopFollowRepo := repository.NewShopFollowsRepository(mysqldb)
+	refreshTokenRepo := repository.NewRefreshTokensRepository(mysqldb)
+	passwordResetRepo := repository.NewPasswordResetsRepository(mysqldb)
+	roleRepo := repository.NewRolesRepository(mysqldb)
+	productVariantRepo := repository.NewProductVariantsRepository(mysqldb)
+	productSearchIndex := SearchIndexInit(v, mysqldb, restClient)
+	slugRedirectRepo := repository.NewProductSlugRedirectsRepository(mysqldb)
+	stockMovementRepo := repository.NewStockMovementsRepository(mysqldb)
+	stockAlertRepo := repository.NewStockAlertsRepository(mysqldb)
+
+	inventorySvc := usecase.NewInventoryService(productRepo, productVariantRepo, stockMovementRepo, stockAlertRepo)
 
-	authUsc := usecase.NewAuthUseCase(userRepo, shopRepo, provcityRepo)
-	userUsc := usecase.NewUsersUseCase(userRepo, addressRepo, provcityRepo)
-	shopUsc := usecase.NewShopsUseCase(shopRepo)
-	productUsc := usecase.NewProductsUseCase(productRepo, shopRepo, productImageRepo, categoryRepo)
-	categoryUsc := usecase.NewCategoriesUseCase(categoryRepo, shopRepo, productRepo)
-	trxUsc := usecase.NewTrxUseCase(trxRepo, trxDetailRepo, productLogRepo, productRepo, addressRepo, productImageRepo)
+	emailVerification := EmailVerificationInit(v, apps)
+	authUsc := usecase.NewAuthUseCase(userRepo, shopRepo, provcityRepo, refreshTokenRepo, AuthInit(v), passwordResetRepo, otpSender, passwordReset, mail, emailVerification, loginAttemptStore, loginGuard, roleRepo)
+	userUsc := usecase.NewUsersUseCase(userRepo, addressRepo, provcityRepo, mail, emailVerification)
+	shopUsc := usecase.NewShopsUseCase(shopRepo, productRepo, categoryRepo, provcityRepo, shop.MaxPhotoSizeKB*1024, shop.RenameInterval, fileStorage)
+	productUsc := usecase.NewProductsUseCase(productRepo, shopRepo, productImageRepo, categoryRepo, wishlistRepo, productVariantRepo, productSearchIndex, slugRedirectRepo, productLogRepo, ProductPhotoLimitsInit(v), fileStorage, inventorySvc)
+	categoryUsc := usecase.NewCategoriesUseCase(categoryRepo, shopRepo, productRepo, productSearchIndex)
+	trxUsc := usecase.NewTrxUseCase(trxRepo, trxDetailRepo, productLogRepo, productRepo, addressRepo, productImageRepo, productVariantRepo, productSearchIndex, fileStorage, inventorySvc)
 	provCityUsc := usecase.NewProvcityUseCase(provcityRepo)
+	wishlistUsc := usecase.NewWishlistsUseCase(wishlistRepo, productRepo, fileStorage)
+	shopFollowUsc := usecase.NewShopFollowsUseCase(shopFollowRepo, shopRepo, fileStorage)
+	roleUsc := usecase.NewRolesUseCase(roleRepo, userRepo)
+	moderationUsc := usecase.NewModerationUseCase(shopRepo, productRepo, userRepo, productSearchIndex, mail)
+	uploadsUsc := usecase.NewUploadsUseCase(productImageRepo, productVariantRepo, shopRepo, fileStorage)
+	stockAlertUsc := usecase.NewStockAlertsUseCase(stockAlertRepo, shopRepo, userRepo, mail)
 
 	return &Container{
-		Apps:          &apps,
-		Mysqldb:       mysqldb,
-		AuthUsc:       authUsc,
-		UsersUsc:      userUsc,
-		ShopsUsc:      shopUsc,
-		ProductsUsc:   productUsc,
-		CategoriesUsc: categoryUsc,
-		TrxUsc:        trxUsc,
-		ProvcityUsc:   provCityUsc,
+		Apps:           &apps,
+		Mysqldb:        mysqldb,
+		AuthUsc:        authUsc,
+		UsersUsc:       userUsc,
+		ShopsUsc:       shopUsc,
+		ProductsUsc:    productUsc,
+		CategoriesUsc:  categoryUsc,
+		TrxUsc:         trxUsc,
+		ProvcityUsc:    provCityUsc,
+		WishlistsUsc:   wishlistUsc,
+		ShopFollowsUsc: shopFollowUsc,
+		ModerationUsc:  moderationUsc,
+		RolesUsc:       roleUsc,
+		UploadsUsc:     uploadsUsc,
+		StockAlertsUsc: stockAlertUsc,
+		Storage:        fileStorage,
+		Uploads:        &uploads,
+		Alerts:         &alerts,
 	}
 }
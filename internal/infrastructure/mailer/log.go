@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"backend-evermos/internal/helper"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LogMailer writes emails to the log instead of sending them, for
+// development setups without an SMTP server.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("Email to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body), nil)
+	return nil
+}
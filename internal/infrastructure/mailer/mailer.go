@@ -0,0 +1,14 @@
+package mailer
+
+import "context"
+
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
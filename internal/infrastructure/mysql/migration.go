@@ -3,25 +3,307 @@ package mysql
 import (
 	"backend-evermos/internal/helper"
 	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/utils"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"gorm.io/gorm"
 )
 
 func RunMigration(mysqlDB *gorm.DB) {
+	if err := dropDuplicates(mysqlDB, &entity.Wishlist{}, "user_id", "product_id"); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to drop duplicate wishlists", err)
+	}
+
+	if err := dropDuplicates(mysqlDB, &entity.ShopFollow{}, "user_id", "shop_id"); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to drop duplicate shop follows", err)
+	}
+
+	if err := uniqueShopSlugs(mysqlDB); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to make shop slugs unique", err)
+	}
+
+	if err := uniqueProductSlugs(mysqlDB); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to make product slugs unique", err)
+	}
+
+	if err := unversionProductLogs(mysqlDB); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to unversion legacy product logs", err)
+	}
+
 	err := mysqlDB.AutoMigrate(
+		&entity.Permission{},
+		&entity.Role{},
 		&entity.User{},
 		&entity.Address{},
 		&entity.Shop{},
 		&entity.Product{},
 		&entity.ProductImage{},
+		&entity.ProductOption{},
+		&entity.ProductVariant{},
 		&entity.Category{},
 		&entity.Trx{},
 		&entity.TrxDetail{},
 		&entity.ProductLog{},
+		&entity.Wishlist{},
+		&entity.ShopFollow{},
+		&entity.ProductSlugRedirect{},
+		&entity.StockMovement{},
+		&entity.StockAlert{},
+		&entity.RefreshToken{},
+		&entity.PasswordReset{},
+		&entity.LoginAttempt{},
 	)
 	if err != nil {
 		helper.Logger(helper.LoggerLevelError, "Failed Database Migrated", err)
 	}
 
+	if err := backfillProductLogVariants(mysqlDB); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to backfill product log variants", err)
+	}
+
+	if err := seedRoles(mysqlDB); err != nil {
+		helper.Logger(helper.LoggerLevelError, "Failed to seed roles", err)
+	}
+
 	helper.Logger(helper.LoggerLevelInfo, "Database Migrated", nil)
 }
+
+// seedRoles syncs the roles and their permissions to entity.RolePermissions
+// and gives users without any role the default ones, plus the admin role to
+// users created before roles existed with IsAdmin set.
+func seedRoles(mysqlDB *gorm.DB) error {
+	permissions := map[string]entity.Permission{}
+	for _, name := range entity.Permissions {
+		permission := entity.Permission{Name: name}
+		if err := mysqlDB.Where("name = ?", name).FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+		permissions[name] = permission
+	}
+
+	roles := map[string]entity.Role{}
+	for name, names := range entity.RolePermissions {
+		role := entity.Role{Name: name}
+		if err := mysqlDB.Where("name = ?", name).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+
+		var rolePermissions []entity.Permission
+		for _, permission := range names {
+			rolePermissions = append(rolePermissions, permissions[permission])
+		}
+		if err := mysqlDB.Model(&role).Association("Permissions").Replace(rolePermissions); err != nil {
+			return err
+		}
+		roles[name] = role
+	}
+
+	var users []entity.User
+	if err := mysqlDB.Where("NOT EXISTS (SELECT 1 FROM user_roles WHERE user_roles.user_id = users.id)").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		var userRoles []entity.Role
+		for _, name := range entity.DefaultUserRoles {
+			userRoles = append(userRoles, roles[name])
+		}
+		if user.IsAdmin {
+			userRoles = append(userRoles, roles[entity.RoleAdmin])
+		}
+
+		if err := mysqlDB.Model(&user).Association("Roles").Append(userRoles); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropDuplicates prepares a table for a unique index on columns: soft
+// deleted rows are purged, since they would hold the index too, and of
+// duplicate rows only the oldest is kept.
+func dropDuplicates(mysqlDB *gorm.DB, model interface{}, columns ...string) error {
+	if !mysqlDB.Migrator().HasTable(model) {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: mysqlDB}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+
+	if err := mysqlDB.Unscoped().Where("deleted_at IS NOT NULL").Delete(model).Error; err != nil {
+		return err
+	}
+
+	var join []string
+	for _, column := range columns {
+		join = append(join, fmt.Sprintf("newer.%s = older.%s", column, column))
+	}
+
+	return mysqlDB.Exec(fmt.Sprintf("DELETE newer FROM %s newer JOIN %s older ON %s AND newer.id > older.id",
+		table, table, strings.Join(join, " AND "))).Error
+}
+
+// unversionProductLogs prepares product_logs for the unique version index.
+// Snapshots written at checkout before versioning got version 0, they are
+// set to NULL so they do not collide, and the old non unique index is
+// dropped.
+func unversionProductLogs(mysqlDB *gorm.DB) error {
+	migrator := mysqlDB.Migrator()
+	if !migrator.HasColumn(&entity.ProductLog{}, "version") {
+		return nil
+	}
+
+	if migrator.HasIndex(&entity.ProductLog{}, "idx_product_logs_version") {
+		if err := migrator.DropIndex(&entity.ProductLog{}, "idx_product_logs_version"); err != nil {
+			return err
+		}
+	}
+
+	return mysqlDB.Exec("UPDATE product_logs SET version = NULL WHERE version = 0").Error
+}
+
+// backfillProductLogVariants moves the variant of snapshots written before
+// versioning, which was kept in the variant_id, variant_sku and
+// variant_options columns, into Variants and the ordered variant into
+// trx_details, so old transactions still show it.
+func backfillProductLogVariants(mysqlDB *gorm.DB) error {
+	if !mysqlDB.Migrator().HasColumn(&entity.ProductLog{}, "variant_id") {
+		return nil
+	}
+
+	var logs []struct {
+		ID             uint
+		VariantID      uint
+		VariantSKU     string
+		VariantOptions string
+		ResellerPrice  string
+		ConsumerPrice  string
+	}
+	err := mysqlDB.Table("product_logs").
+		Select("id", "variant_id", "variant_sku", "variant_options", "reseller_price", "consumer_price").
+		Where("variant_id IS NOT NULL AND variants IS NULL").
+		Scan(&logs).Error
+	if err != nil {
+		return err
+	}
+
+	for _, log := range logs {
+		var options map[string]string
+		if log.VariantOptions != "" {
+			if err := json.Unmarshal([]byte(log.VariantOptions), &options); err != nil {
+				return err
+			}
+		}
+
+		variants := []entity.ProductLogVariant{{
+			ID:            log.VariantID,
+			SKU:           log.VariantSKU,
+			Options:       options,
+			ResellerPrice: log.ResellerPrice,
+			ConsumerPrice: log.ConsumerPrice,
+		}}
+		if err := mysqlDB.Model(&entity.ProductLog{Model: gorm.Model{ID: log.ID}}).Select("Variants").Updates(&entity.ProductLog{Variants: variants}).Error; err != nil {
+			return err
+		}
+	}
+
+	return mysqlDB.Exec(`UPDATE trx_details JOIN product_logs ON product_logs.id = trx_details.product_log_id
+		SET trx_details.variant_id = product_logs.variant_id
+		WHERE trx_details.variant_id IS NULL AND product_logs.variant_id IS NOT NULL`).Error
+}
+
+// uniqueShopSlugs prepares shops for the unique handle index. Shops without
+// a slug get one, deleted shops included, and of shops sharing a slug all
+// but the oldest get a suffixed one. The old non unique index is dropped.
+func uniqueShopSlugs(mysqlDB *gorm.DB) error {
+	migrator := mysqlDB.Migrator()
+	if !migrator.HasColumn(&entity.Shop{}, "slug") {
+		return nil
+	}
+
+	if migrator.HasIndex(&entity.Shop{}, "idx_shops_slug") {
+		if err := migrator.DropIndex(&entity.Shop{}, "idx_shops_slug"); err != nil {
+			return err
+		}
+	}
+
+	var shops []entity.Shop
+	err := mysqlDB.Unscoped().
+		Where("slug = '' OR slug IS NULL OR EXISTS (SELECT 1 FROM (SELECT id, slug FROM shops) older WHERE older.slug = shops.slug AND older.id < shops.id)").
+		Order("id ASC").
+		Find(&shops).Error
+	if err != nil {
+		return err
+	}
+
+	for _, shop := range shops {
+		base := shop.Slug
+		if base == "" {
+			base = utils.ShopHandleFromName(shop.ShopName)
+		}
+
+		var taken []string
+		if err := mysqlDB.Unscoped().Model(&entity.Shop{}).Where("slug = ? OR slug LIKE ?", base, base+"-%").Pluck("slug", &taken).Error; err != nil {
+			return err
+		}
+
+		if err := mysqlDB.Unscoped().Model(&shop).Update("slug", utils.UniqueSlug(base, taken)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueProductSlugs prepares products for the unique slug index per shop.
+// Products created before slugs were generated automatically get one,
+// deleted products included, and of products of a shop sharing a slug all
+// but the oldest get a suffixed one. The old non unique index is dropped.
+func uniqueProductSlugs(mysqlDB *gorm.DB) error {
+	migrator := mysqlDB.Migrator()
+	if !migrator.HasColumn(&entity.Product{}, "slug") {
+		return nil
+	}
+
+	if migrator.HasIndex(&entity.Product{}, "idx_products_shop_slug") {
+		if err := migrator.DropIndex(&entity.Product{}, "idx_products_shop_slug"); err != nil {
+			return err
+		}
+	}
+
+	var products []entity.Product
+	err := mysqlDB.Unscoped().
+		Where("slug = '' OR slug IS NULL OR EXISTS (SELECT 1 FROM (SELECT id, shop_id, slug FROM products) older WHERE older.shop_id = products.shop_id AND older.slug = products.slug AND older.id < products.id)").
+		Order("id ASC").
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		base := product.Slug
+		if base == "" {
+			base = utils.GenerateSlug(product.ProductName)
+		}
+		if base == "" {
+			base = "produk"
+		}
+
+		var taken []string
+		if err := mysqlDB.Unscoped().Model(&entity.Product{}).Where("shop_id = ? AND (slug = ? OR slug LIKE ?)", product.ShopID, base, base+"-%").Pluck("slug", &taken).Error; err != nil {
+			return err
+		}
+
+		if err := mysqlDB.Unscoped().Model(&product).Update("slug", utils.UniqueSlug(base, taken)).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -32,7 +32,10 @@ func DatabaseInit(v *viper.Viper) *gorm.DB {
 
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local", mysqlConfig.Username, mysqlConfig.Password, mysqlConfig.Host, mysqlConfig.Port, mysqlConfig.DbName)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// Unique index violations come back as gorm.ErrDuplicatedKey.
+		TranslateError: true,
+	})
 	if err != nil {
 		// helper.Logger(helper.LoggerLevelPanic, fmt.Sprintf("Cannot conenct to database : %s", err.Error()), err)
 		panic(err)
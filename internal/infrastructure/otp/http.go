@@ -0,0 +1,44 @@
+package otp
+
+import (
+	"backend-evermos/internal/infrastructure/restclient"
+	"context"
+	"net/http"
+)
+
+type HTTPConfig struct {
+	URL    string
+	APIKey string
+	// Channel is passed to the gateway as is, e.g. sms or whatsapp.
+	Channel string
+}
+
+// HTTPSender posts one time passwords as JSON to an SMS or WhatsApp gateway,
+// authenticating with a bearer API key when one is configured.
+type HTTPSender struct {
+	client *restclient.RestClient
+	conf   HTTPConfig
+}
+
+func NewHTTPSender(client *restclient.RestClient, conf HTTPConfig) *HTTPSender {
+	return &HTTPSender{client: client, conf: conf}
+}
+
+type httpSendReq struct {
+	To      string `json:"to"`
+	Channel string `json:"channel"`
+	Message string `json:"message"`
+}
+
+func (s *HTTPSender) Send(ctx context.Context, msg Message) error {
+	headers := map[string]string{}
+	if s.conf.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.conf.APIKey
+	}
+
+	return s.client.Do(http.MethodPost, s.conf.URL, headers, httpSendReq{
+		To:      msg.To,
+		Channel: s.conf.Channel,
+		Message: msg.Body,
+	}, nil)
+}
@@ -0,0 +1,40 @@
+package otp
+
+import (
+	"backend-evermos/internal/helper"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender writes one time passwords to the log, and appends them to a file
+// when a path is set, for development setups without a gateway.
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogSender(path string) *LogSender {
+	return &LogSender{path: path}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("OTP to %s: %s", msg.To, msg.Body), nil)
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), msg.To, msg.Body)
+	return err
+}
@@ -0,0 +1,15 @@
+package otp
+
+import "context"
+
+type Message struct {
+	// To is the phone number the code is sent to, as the user registered it.
+	To   string
+	Body string
+}
+
+// OTPSender delivers one time passwords to a phone number, over SMS or
+// WhatsApp depending on the implementation.
+type OTPSender interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -1,7 +1,9 @@
 package restclient
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -27,3 +29,43 @@ func (r *RestClient) Get(url string, result interface{}) error {
 	bodyBytes, _ := io.ReadAll(resp.Body)
 	return json.Unmarshal(bodyBytes, &result)
 }
+
+// Do sends body as JSON with the given headers and decodes the response into
+// result when it is not nil. Non 2xx responses are returned as errors.
+func (r *RestClient) Do(method, url string, headers map[string]string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %d: %s", method, url, resp.StatusCode, string(bodyBytes))
+	}
+
+	if result == nil || len(bodyBytes) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(bodyBytes, result)
+}
@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+type FileInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Storage stores uploaded files under slash separated keys such as
+// "products/abc.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List returns every file whose key starts with prefix.
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+	// URL returns the public URL of key.
+	URL(key string) string
+	// SignedURL returns a URL granting temporary read access to key.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
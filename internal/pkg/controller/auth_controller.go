@@ -11,6 +11,13 @@ import (
 type AuthController interface {
 	Login(ctx *fiber.Ctx) error
 	Register(ctx *fiber.Ctx) error
+	RefreshToken(ctx *fiber.Ctx) error
+	Logout(ctx *fiber.Ctx) error
+	ForgotPassword(ctx *fiber.Ctx) error
+	ResetPassword(ctx *fiber.Ctx) error
+	VerifyEmail(ctx *fiber.Ctx) error
+	ResendEmailVerification(ctx *fiber.Ctx) error
+	UnlockLogin(ctx *fiber.Ctx) error
 }
 
 type AuthControllerImpl struct {
@@ -36,7 +43,7 @@ func (uc *AuthControllerImpl) Login(ctx *fiber.Ctx) error {
 		})
 	}
 
-	res, err := uc.authUsc.Login(c, *data)
+	res, err := uc.authUsc.Login(c, *data, ctx.IP())
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
 			Status:  false,
@@ -84,3 +91,203 @@ func (uc *AuthControllerImpl) Register(ctx *fiber.Ctx) error {
 		Data:    "Register Succeed",
 	})
 }
+
+func (uc *AuthControllerImpl) RefreshToken(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	data := new(model.RefreshTokenReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.authUsc.RefreshToken(c, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) Logout(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	data := new(model.LogoutReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.authUsc.Logout(c, userID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) ForgotPassword(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	data := new(model.ForgotPasswordReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.authUsc.ForgotPassword(c, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) ResetPassword(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	data := new(model.ResetPasswordReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.authUsc.ResetPassword(c, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) VerifyEmail(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	res, err := uc.authUsc.VerifyEmail(c, ctx.Query("token"))
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) ResendEmailVerification(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	res, err := uc.authUsc.ResendEmailVerification(c, userID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *AuthControllerImpl) UnlockLogin(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	adminID := helper.AuthUserID(ctx)
+
+	data := new(model.LoginUnlockReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.authUsc.UnlockLogin(c, adminID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
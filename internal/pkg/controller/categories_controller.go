@@ -11,9 +11,12 @@ import (
 type CategoriesController interface {
 	AddCategory(ctx *fiber.Ctx) error
 	GetCategories(ctx *fiber.Ctx) error
+	GetCategoryTree(ctx *fiber.Ctx) error
 	GetCategoryByID(ctx *fiber.Ctx) error
 	UpdateCategoryByID(ctx *fiber.Ctx) error
+	MoveCategory(ctx *fiber.Ctx) error
 	DeleteCategoryByID(ctx *fiber.Ctx) error
+	MergeCategory(ctx *fiber.Ctx) error
 }
 
 type CategoriesControllerImpl struct {
@@ -78,6 +81,27 @@ func (uc *CategoriesControllerImpl) GetCategories(ctx *fiber.Ctx) error {
 	})
 }
 
+func (uc *CategoriesControllerImpl) GetCategoryTree(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	res, err := uc.categoriesUseCase.GetCategoryTree(c)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
 func (uc *CategoriesControllerImpl) GetCategoryByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
 	categoryID := ctx.Params("id")
@@ -149,6 +173,47 @@ func (uc *CategoriesControllerImpl) UpdateCategoryByID(ctx *fiber.Ctx) error {
 	})
 }
 
+func (uc *CategoriesControllerImpl) MoveCategory(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	categoryID := ctx.Params("id")
+
+	if categoryID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{"Bad request"},
+			Data:    nil,
+		})
+	}
+
+	data := new(model.CategoryReqMove)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.categoriesUseCase.MoveCategory(c, categoryID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
 func (uc *CategoriesControllerImpl) DeleteCategoryByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
 	categoryID := ctx.Params("id")
@@ -162,7 +227,17 @@ func (uc *CategoriesControllerImpl) DeleteCategoryByID(ctx *fiber.Ctx) error {
 		})
 	}
 
-	res, err := uc.categoriesUseCase.DeleteCategoryByID(c, categoryID)
+	params := new(model.CategoryReqDelete)
+	if err := ctx.QueryParser(params); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.categoriesUseCase.DeleteCategoryByID(c, categoryID, *params)
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
 			Status:  false,
@@ -179,3 +254,44 @@ func (uc *CategoriesControllerImpl) DeleteCategoryByID(ctx *fiber.Ctx) error {
 		Data:    res,
 	})
 }
+
+func (uc *CategoriesControllerImpl) MergeCategory(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	categoryID := ctx.Params("id")
+
+	if categoryID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{"Bad request"},
+			Data:    nil,
+		})
+	}
+
+	data := new(model.CategoryReqMerge)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.categoriesUseCase.MergeCategory(c, categoryID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModerationController interface {
+	SuspendShop(ctx *fiber.Ctx) error
+	UnsuspendShop(ctx *fiber.Ctx) error
+	TakedownProduct(ctx *fiber.Ctx) error
+	RestoreProduct(ctx *fiber.Ctx) error
+}
+
+type ModerationControllerImpl struct {
+	moderationUseCase usecase.ModerationUseCase
+}
+
+func NewModerationController(moderationUseCase usecase.ModerationUseCase) ModerationController {
+	return &ModerationControllerImpl{
+		moderationUseCase: moderationUseCase,
+	}
+}
+
+func (uc *ModerationControllerImpl) SuspendShop(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+
+	data := new(model.ModerationReqCreate)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.moderationUseCase.SuspendShop(c, id, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ModerationControllerImpl) UnsuspendShop(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+
+	data := new(model.ModerationReqLift)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.moderationUseCase.UnsuspendShop(c, id, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ModerationControllerImpl) TakedownProduct(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+
+	data := new(model.ModerationReqCreate)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.moderationUseCase.TakedownProduct(c, id, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ModerationControllerImpl) RestoreProduct(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+
+	data := new(model.ModerationReqLift)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.moderationUseCase.RestoreProduct(c, id, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"backend-evermos/internal/helper"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/usecase"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,8 +13,16 @@ type ProductsController interface {
 	AddProduct(ctx *fiber.Ctx) error
 	GetAllProducts(ctx *fiber.Ctx) error
 	GetProductByID(ctx *fiber.Ctx) error
+	GetProductBySlug(ctx *fiber.Ctx) error
 	UpdateProductByID(ctx *fiber.Ctx) error
 	DeleteProductByID(ctx *fiber.Ctx) error
+	AddProductPhotos(ctx *fiber.Ctx) error
+	ReplaceProductPhoto(ctx *fiber.Ctx) error
+	DeleteProductPhoto(ctx *fiber.Ctx) error
+	ReorderProductPhotos(ctx *fiber.Ctx) error
+	SetPrimaryProductPhoto(ctx *fiber.Ctx) error
+	GetStockHistory(ctx *fiber.Ctx) error
+	GetProductHistory(ctx *fiber.Ctx) error
 }
 
 type ProductsControllerImpl struct {
@@ -28,7 +37,7 @@ func NewProductsController(productsUseCase usecase.ProductsUseCase) ProductsCont
 
 func (uc *ProductsControllerImpl) AddProduct(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	data := new(model.ProductReqCreate)
 	if err := ctx.BodyParser(data); err != nil {
@@ -51,16 +60,8 @@ func (uc *ProductsControllerImpl) AddProduct(ctx *fiber.Ctx) error {
 	}
 
 	files := form.File["photos"]
-	if len(files) != 2 {
-		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
-			Status:  false,
-			Message: "Failed to POST data",
-			Errors:  []string{"Harus melengkapi foto produk"},
-			Data:    nil,
-		})
-	}
-
-	res, err := uc.productsUseCase.CreateProduct(c, userID, *data, files)
+	variantFiles := form.File["variant_photos"]
+	res, err := uc.productsUseCase.CreateProduct(c, userID, *data, files, variantFiles)
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
 			Status:  false,
@@ -80,6 +81,7 @@ func (uc *ProductsControllerImpl) AddProduct(ctx *fiber.Ctx) error {
 
 func (uc *ProductsControllerImpl) GetAllProducts(ctx *fiber.Ctx) error {
 	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
 
 	filter := new(model.ProductsFilter)
 	if err := ctx.QueryParser(filter); err != nil {
@@ -90,14 +92,17 @@ func (uc *ProductsControllerImpl) GetAllProducts(ctx *fiber.Ctx) error {
 		})
 	}
 
-	res, err := uc.productsUseCase.GetAllProducts(c, model.ProductsFilter{
+	res, err := uc.productsUseCase.GetAllProducts(c, userID, model.ProductsFilter{
 		Limit:       filter.Limit,
 		Page:        filter.Page,
 		ProductName: filter.ProductName,
+		Sort:        filter.Sort,
 		CategoryID:  filter.CategoryID,
 		ShopID:      filter.ShopID,
 		MaxPrice:    filter.MaxPrice,
 		MinPrice:    filter.MinPrice,
+
+		IncludeOutOfStock: filter.IncludeOutOfStock,
 	})
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
@@ -137,9 +142,37 @@ func (uc *ProductsControllerImpl) GetProductByID(ctx *fiber.Ctx) error {
 	})
 }
 
+func (uc *ProductsControllerImpl) GetProductBySlug(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	shopSlug := ctx.Params("shopSlug")
+	slug := ctx.Params("slug")
+
+	res, redirectSlug, err := uc.productsUseCase.GetProductBySlug(c, shopSlug, slug)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	if redirectSlug != "" {
+		path := ctx.Path()
+		return ctx.Redirect(path[:strings.LastIndex(path, "/")+1]+redirectSlug, fiber.StatusMovedPermanently)
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
 func (uc *ProductsControllerImpl) UpdateProductByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	productID := ctx.Params("id")
 
 	data := new(model.ProductReqUpdate)
@@ -163,7 +196,8 @@ func (uc *ProductsControllerImpl) UpdateProductByID(ctx *fiber.Ctx) error {
 	}
 
 	files := form.File["photos"]
-	res, err := uc.productsUseCase.UpdateProductByID(c, userID, productID, *data, files)
+	variantFiles := form.File["variant_photos"]
+	res, err := uc.productsUseCase.UpdateProductByID(c, userID, productID, *data, files, variantFiles)
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
 			Status:  false,
@@ -183,7 +217,7 @@ func (uc *ProductsControllerImpl) UpdateProductByID(ctx *fiber.Ctx) error {
 
 func (uc *ProductsControllerImpl) DeleteProductByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	productID := ctx.Params("id")
 	if productID == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
@@ -211,3 +245,213 @@ func (uc *ProductsControllerImpl) DeleteProductByID(ctx *fiber.Ctx) error {
 		Data:    res,
 	})
 }
+
+func (uc *ProductsControllerImpl) AddProductPhotos(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+
+	form, errFile := ctx.MultipartForm()
+	if errFile != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{errFile.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.productsUseCase.AddProductPhotos(c, userID, productID, form.File["photos"])
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) ReplaceProductPhoto(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+	photoID := ctx.Params("photoId")
+
+	file, errFile := ctx.FormFile("photo")
+	if errFile != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{errFile.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.productsUseCase.ReplaceProductPhoto(c, userID, productID, photoID, file)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) DeleteProductPhoto(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+	photoID := ctx.Params("photoId")
+
+	res, err := uc.productsUseCase.DeleteProductPhoto(c, userID, productID, photoID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to DELETE data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) ReorderProductPhotos(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+
+	data := new(model.ProductPhotosReorderReq)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.productsUseCase.ReorderProductPhotos(c, userID, productID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) SetPrimaryProductPhoto(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+	photoID := ctx.Params("photoId")
+
+	res, err := uc.productsUseCase.SetPrimaryProductPhoto(c, userID, productID, photoID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) GetStockHistory(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+
+	filter := new(model.StockMovementsFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.productsUseCase.GetStockHistory(c, userID, productID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ProductsControllerImpl) GetProductHistory(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("id")
+
+	filter := new(model.ProductHistoryFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.productsUseCase.GetProductHistory(c, userID, productID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
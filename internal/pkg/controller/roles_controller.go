@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RolesController interface {
+	GetRoles(ctx *fiber.Ctx) error
+	GetUserRoles(ctx *fiber.Ctx) error
+	UpdateUserRoles(ctx *fiber.Ctx) error
+}
+
+type RolesControllerImpl struct {
+	rolesUseCase usecase.RolesUseCase
+}
+
+func NewRolesController(rolesUseCase usecase.RolesUseCase) RolesController {
+	return &RolesControllerImpl{
+		rolesUseCase: rolesUseCase,
+	}
+}
+
+func (uc *RolesControllerImpl) GetRoles(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	res, err := uc.rolesUseCase.GetRoles(c)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *RolesControllerImpl) GetUserRoles(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+
+	res, err := uc.rolesUseCase.GetUserRoles(c, id)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *RolesControllerImpl) UpdateUserRoles(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	id := ctx.Params("id")
+	actor, _ := helper.GetAuthUser(ctx)
+
+	data := new(model.UserRolesReqUpdate)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.rolesUseCase.UpdateUserRoles(c, actor, id, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to PUT data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShopFollowsController interface {
+	FollowShop(ctx *fiber.Ctx) error
+	UnfollowShop(ctx *fiber.Ctx) error
+	GetMyFollowing(ctx *fiber.Ctx) error
+	GetMyFeed(ctx *fiber.Ctx) error
+}
+
+type ShopFollowsControllerImpl struct {
+	shopFollowsUseCase usecase.ShopFollowsUseCase
+}
+
+func NewShopFollowsController(shopFollowsUseCase usecase.ShopFollowsUseCase) ShopFollowsController {
+	return &ShopFollowsControllerImpl{
+		shopFollowsUseCase: shopFollowsUseCase,
+	}
+}
+
+func (uc *ShopFollowsControllerImpl) FollowShop(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	shopID := ctx.Params("id")
+
+	res, err := uc.shopFollowsUseCase.FollowShop(c, userID, shopID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ShopFollowsControllerImpl) UnfollowShop(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	shopID := ctx.Params("id")
+
+	res, err := uc.shopFollowsUseCase.UnfollowShop(c, userID, shopID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to DELETE data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ShopFollowsControllerImpl) GetMyFollowing(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	filter := new(model.ShopFollowsFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.shopFollowsUseCase.GetMyFollowing(c, userID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ShopFollowsControllerImpl) GetMyFeed(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	filter := new(model.FeedFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.shopFollowsUseCase.GetMyFeed(c, userID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"backend-evermos/internal/helper"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/usecase"
+	"mime/multipart"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,6 +14,9 @@ type ShopsController interface {
 	GetMyShop(ctx *fiber.Ctx) error
 	UpdateShopByID(ctx *fiber.Ctx) error
 	GetShopByID(ctx *fiber.Ctx) error
+	GetShopStorefront(ctx *fiber.Ctx) error
+	GetShopByHandle(ctx *fiber.Ctx) error
+	CheckShopHandle(ctx *fiber.Ctx) error
 	GetAllShops(ctx *fiber.Ctx) error
 }
 
@@ -27,7 +32,7 @@ func NewShopsController(shopsUseCase usecase.ShopsUseCase) ShopsController {
 
 func (uc *ShopsControllerImpl) GetMyShop(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	res, err := uc.shopsUseCase.GetMyShop(c, userID)
 	if err != nil {
@@ -49,7 +54,7 @@ func (uc *ShopsControllerImpl) GetMyShop(ctx *fiber.Ctx) error {
 
 func (uc *ShopsControllerImpl) UpdateShopByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	shopID := ctx.Params("id")
 
 	data := new(model.ShopReqUpdate)
@@ -62,7 +67,7 @@ func (uc *ShopsControllerImpl) UpdateShopByID(ctx *fiber.Ctx) error {
 		})
 	}
 
-	fileHeader, errFile := ctx.FormFile("photo")
+	photo, errFile := optionalFormFile(ctx, "photo")
 	if errFile != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
 			Status:  false,
@@ -71,7 +76,16 @@ func (uc *ShopsControllerImpl) UpdateShopByID(ctx *fiber.Ctx) error {
 		})
 	}
 
-	res, err := uc.shopsUseCase.UpdateShopByID(c, shopID, userID, *data, fileHeader)
+	banner, errFile := optionalFormFile(ctx, "banner")
+	if errFile != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to PUT data",
+			Errors:  []string{errFile.Error()},
+		})
+	}
+
+	res, err := uc.shopsUseCase.UpdateShopByID(c, shopID, userID, *data, photo, banner)
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
 			Status:  false,
@@ -111,6 +125,80 @@ func (uc *ShopsControllerImpl) GetShopByID(ctx *fiber.Ctx) error {
 	})
 }
 
+func (uc *ShopsControllerImpl) GetShopStorefront(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	shopID := ctx.Params("id")
+
+	res, err := uc.shopsUseCase.GetShopStorefront(c, shopID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ShopsControllerImpl) GetShopByHandle(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	handle := ctx.Params("handle")
+
+	res, err := uc.shopsUseCase.GetShopByHandle(c, handle)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *ShopsControllerImpl) CheckShopHandle(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+
+	filter := new(model.ShopHandleFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.shopsUseCase.CheckShopHandle(c, filter.Handle)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
 func (uc *ShopsControllerImpl) GetAllShops(ctx *fiber.Ctx) error {
 	c := ctx.Context()
 
@@ -143,3 +231,22 @@ func (uc *ShopsControllerImpl) GetAllShops(ctx *fiber.Ctx) error {
 		Data:    res,
 	})
 }
+
+// optionalFormFile returns the uploaded file named name, or nil when the
+// request has none.
+func optionalFormFile(ctx *fiber.Ctx, name string) (*multipart.FileHeader, error) {
+	if !strings.HasPrefix(string(ctx.Request().Header.ContentType()), fiber.MIMEMultipartForm) {
+		return nil, nil
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+
+	if files := form.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type StockAlertsController interface {
+	GetMyStockAlerts(ctx *fiber.Ctx) error
+}
+
+type StockAlertsControllerImpl struct {
+	stockAlertsUseCase usecase.StockAlertsUseCase
+}
+
+func NewStockAlertsController(stockAlertsUseCase usecase.StockAlertsUseCase) StockAlertsController {
+	return &StockAlertsControllerImpl{
+		stockAlertsUseCase: stockAlertsUseCase,
+	}
+}
+
+func (uc *StockAlertsControllerImpl) GetMyStockAlerts(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	filter := new(model.StockAlertsFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.stockAlertsUseCase.GetMyStockAlerts(c, userID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/usecase"
 
@@ -26,7 +27,7 @@ func NewTrxController(trxUseCase usecase.TrxUseCase) TrxController {
 
 func (uc *TrxControllerImpl) CreateTrx(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	data := new(model.TrxReqCreate)
 	if err := ctx.BodyParser(data); err != nil {
@@ -58,9 +59,14 @@ func (uc *TrxControllerImpl) CreateTrx(ctx *fiber.Ctx) error {
 
 func (uc *TrxControllerImpl) GetTrxByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	trxID := ctx.Params("id")
 
+	// Staff may look up the transactions of any user.
+	if helper.HasPermission(ctx, entity.PermTrxReadAll) {
+		userID = ""
+	}
+
 	res, err := uc.trxUseCase.GetTrxByID(c, userID, trxID)
 	if err != nil {
 		return ctx.Status(err.Code).JSON(helper.Response{
@@ -81,7 +87,7 @@ func (uc *TrxControllerImpl) GetTrxByID(ctx *fiber.Ctx) error {
 
 func (uc *TrxControllerImpl) GetAllTrx(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	filter := new(model.TrxFilter)
 	if err := ctx.QueryParser(filter); err != nil {
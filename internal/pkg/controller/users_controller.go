@@ -33,7 +33,7 @@ func NewUsersController(usersUseCase usecase.UsersUseCase) UsersController {
 
 func (uc *UsersControllerImpl) GetMyProfile(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	res, err := uc.usersUseCase.GetMyProfile(c, userID)
 	if err != nil {
@@ -55,7 +55,7 @@ func (uc *UsersControllerImpl) GetMyProfile(ctx *fiber.Ctx) error {
 
 func (uc *UsersControllerImpl) UpdateMyProfile(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	data := new(model.UserReqUpdate)
 	if err := ctx.BodyParser(data); err != nil {
@@ -87,7 +87,7 @@ func (uc *UsersControllerImpl) UpdateMyProfile(ctx *fiber.Ctx) error {
 
 func (uc *UsersControllerImpl) GetMyAddresses(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	res, err := uc.usersUseCase.GetMyAddresses(c, userID)
 	if err != nil {
@@ -139,7 +139,7 @@ func (uc *UsersControllerImpl) GetAddressByID(ctx *fiber.Ctx) error {
 
 func (uc *UsersControllerImpl) AddAddress(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 
 	data := new(model.AddressReqCreate)
 	if err := ctx.BodyParser(data); err != nil {
@@ -171,7 +171,7 @@ func (uc *UsersControllerImpl) AddAddress(ctx *fiber.Ctx) error {
 
 func (uc *UsersControllerImpl) UpdateAddressByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	addressID := ctx.Params("id")
 	if addressID == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
@@ -212,7 +212,7 @@ func (uc *UsersControllerImpl) UpdateAddressByID(ctx *fiber.Ctx) error {
 
 func (uc *UsersControllerImpl) DeleteAddressByID(ctx *fiber.Ctx) error {
 	c := ctx.Context()
-	userID := ctx.Locals("userid").(string)
+	userID := helper.AuthUserID(ctx)
 	addressID := ctx.Params("id")
 	if addressID == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
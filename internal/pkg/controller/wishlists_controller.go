@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WishlistsController interface {
+	AddWishlist(ctx *fiber.Ctx) error
+	GetMyWishlists(ctx *fiber.Ctx) error
+	DeleteWishlist(ctx *fiber.Ctx) error
+}
+
+type WishlistsControllerImpl struct {
+	wishlistsUseCase usecase.WishlistsUseCase
+}
+
+func NewWishlistsController(wishlistsUseCase usecase.WishlistsUseCase) WishlistsController {
+	return &WishlistsControllerImpl{
+		wishlistsUseCase: wishlistsUseCase,
+	}
+}
+
+func (uc *WishlistsControllerImpl) AddWishlist(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	data := new(model.WishlistReqCreate)
+	if err := ctx.BodyParser(data); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Error()},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.wishlistsUseCase.AddWishlist(c, userID, *data)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to POST data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to POST data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *WishlistsControllerImpl) GetMyWishlists(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+
+	filter := new(model.WishlistsFilter)
+	if err := ctx.QueryParser(filter); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Error()},
+		})
+	}
+
+	res, err := uc.wishlistsUseCase.GetMyWishlists(c, userID, *filter)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to GET data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to GET data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
+
+func (uc *WishlistsControllerImpl) DeleteWishlist(ctx *fiber.Ctx) error {
+	c := ctx.Context()
+	userID := helper.AuthUserID(ctx)
+	productID := ctx.Params("productId")
+	if productID == "" {
+		return ctx.Status(fiber.StatusBadRequest).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Errors:  []string{"Bad request"},
+			Data:    nil,
+		})
+	}
+
+	res, err := uc.wishlistsUseCase.DeleteWishlist(c, userID, productID)
+	if err != nil {
+		return ctx.Status(err.Code).JSON(helper.Response{
+			Status:  false,
+			Message: "Failed to DELETE data",
+			Errors:  []string{err.Err.Error()},
+			Data:    nil,
+		})
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(helper.Response{
+		Status:  true,
+		Message: "Succeed to DELETE data",
+		Errors:  nil,
+		Data:    res,
+	})
+}
@@ -2,7 +2,13 @@ package entity
 
 import "gorm.io/gorm"
 
+// Category is a node of the category tree, a nil ParentID is a root.
+// Siblings are ordered by SortOrder.
 type Category struct {
 	gorm.Model
 	CategoryName string
+	ParentID     *uint  `gorm:"index"`
+	Slug         string `gorm:"type:varchar(255);index"`
+	Icon         string
+	SortOrder    int
 }
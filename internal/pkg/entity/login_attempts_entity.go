@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// LoginAttempt counts the recent failed logins of a phone number or an IP
+// address, Key tells which.
+type LoginAttempt struct {
+	Key         string `gorm:"primaryKey;type:varchar(191)"`
+	Failures    int
+	LastFailure time.Time
+	LockedUntil *time.Time
+}
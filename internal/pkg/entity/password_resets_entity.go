@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordReset is a one time password sent to the phone of a user who
+// forgot their password. Requesting a new code retires the older ones.
+type PasswordReset struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	CodeHash  string `gorm:"type:varchar(60)"`
+	ExpiresAt time.Time
+	Attempts  int
+	UsedAt    *time.Time
+	User      User `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+// PasswordResetUsage is how many codes a user got and how many wrong codes
+// they entered within a period.
+type PasswordResetUsage struct {
+	Codes    int64
+	Attempts int64
+}
@@ -6,4 +6,6 @@ type ProductImage struct {
 	gorm.Model
 	ProductID uint
 	PhotoURL  string
+	Position  int
+	IsPrimary bool
 }
@@ -2,9 +2,16 @@ package entity
 
 import "gorm.io/gorm"
 
+// ProductLog is a versioned snapshot of the content of a product. A version
+// is written whenever the content changes, and transactions point at the
+// version that was current at checkout. ContentHash identifies the content,
+// so an unchanged product reuses its latest version. Snapshots written at
+// checkout before versioning have a NULL version, which keeps them out of
+// the unique index.
 type ProductLog struct {
 	gorm.Model
-	ProductID     uint
+	ProductID     uint `gorm:"uniqueIndex:idx_product_logs_product_version,priority:1"`
+	Version       int  `gorm:"uniqueIndex:idx_product_logs_product_version,priority:2"`
 	ProductName   string
 	Slug          string
 	ResellerPrice string
@@ -12,6 +19,23 @@ type ProductLog struct {
 	Description   string
 	ShopID        uint
 	CategoryID    *uint
+	Variants      []ProductLogVariant `gorm:"serializer:json"`
+	ContentHash   string              `gorm:"type:varchar(64)"`
+	EditorID      *uint
 	Shop          Shop     `gorm:"constraint:OnDelete:SET NULL;"`
 	Category      Category `gorm:"constraint:OnDelete:SET NULL;"`
+	Editor        *User    `gorm:"constraint:OnDelete:SET NULL;"`
+}
+
+type ProductLogVariant struct {
+	ID            uint              `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"options"`
+	ResellerPrice string            `json:"reseller_price"`
+	ConsumerPrice string            `json:"consumer_price"`
+}
+
+type FilterProductLogs struct {
+	Limit, Offset int
+	ProductID     uint
 }
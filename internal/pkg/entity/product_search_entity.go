@@ -0,0 +1,13 @@
+package entity
+
+type ProductSearchDocument struct {
+	ID            uint   `json:"id"`
+	ProductName   string `json:"product_name"`
+	Description   string `json:"description"`
+	ShopID        uint   `json:"shop_id"`
+	CategoryID    uint   `json:"category_id"`
+	ConsumerPrice int    `json:"consumer_price"`
+	Stock         int    `json:"stock"`
+	TotalSold     int    `json:"total_sold"`
+	CreatedAt     int64  `json:"created_at"`
+}
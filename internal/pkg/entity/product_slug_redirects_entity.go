@@ -0,0 +1,11 @@
+package entity
+
+import "gorm.io/gorm"
+
+type ProductSlugRedirect struct {
+	gorm.Model
+	ShopID    uint   `gorm:"index:idx_product_slug_redirects_shop_slug"`
+	Slug      string `gorm:"type:varchar(255);index:idx_product_slug_redirects_shop_slug"`
+	ProductID uint
+	Product   Product `gorm:"constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,21 @@
+package entity
+
+import "gorm.io/gorm"
+
+type ProductOption struct {
+	gorm.Model
+	ProductID  uint
+	OptionName string
+	Values     []string `gorm:"serializer:json"`
+}
+
+type ProductVariant struct {
+	gorm.Model
+	ProductID     uint
+	SKU           string
+	Options       map[string]string `gorm:"serializer:json"`
+	ResellerPrice string
+	ConsumerPrice string
+	Stock         int
+	PhotoURL      string
+}
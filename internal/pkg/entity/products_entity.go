@@ -1,28 +1,50 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Product struct {
 	gorm.Model
-	ProductName   string
-	Slug          string
+	ProductName   string `gorm:"type:longtext;index:idx_products_search,class:FULLTEXT"`
+	Slug          string `gorm:"type:varchar(255);uniqueIndex:idx_products_shop_slug_unique,priority:2"`
 	ResellerPrice string
 	ConsumerPrice string
 	Stock         int
-	Description   string
-	ShopID        uint
-	CategoryID    *uint
-	Shop          Shop           `gorm:"constraint:OnDelete:CASCADE;"`
-	Category      Category       `gorm:"constraint:OnDelete:SET NULL;"`
-	Images        []ProductImage `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
-	ProductLog    ProductLog     `gorm:"foreignKey:ProductID;constraint:OnDelete:SET NULL;"`
+	// LowStockThreshold raises a low stock alert once the stock drops to
+	// it, zero disables the alert.
+	LowStockThreshold int
+	Description       string `gorm:"type:longtext;index:idx_products_search,class:FULLTEXT"`
+	ShopID            uint   `gorm:"uniqueIndex:idx_products_shop_slug_unique,priority:1"`
+	CategoryID        *uint
+	RestockedAt       *time.Time `gorm:"index"`
+	TakenDownAt       *time.Time `gorm:"index"`
+	TakedownReason    string
+	Shop              Shop             `gorm:"constraint:OnDelete:CASCADE;"`
+	Category          Category         `gorm:"constraint:OnDelete:SET NULL;"`
+	Images            []ProductImage   `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	Options           []ProductOption  `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	Variants          []ProductVariant `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE;"`
+	ProductLog        ProductLog       `gorm:"foreignKey:ProductID;constraint:OnDelete:SET NULL;"`
 }
 
+const (
+	ProductSortRelevance = "relevance"
+	ProductSortCheapest  = "termurah"
+	ProductSortPriciest  = "termahal"
+	ProductSortNewest    = "terbaru"
+	ProductSortBestSell  = "terlaris"
+)
+
 type FilterProducts struct {
 	Limit, Offset int
-	ProductName   string
-	CategoryID    uint
+	SearchQuery   string
+	Sort          string
+	CategoryIDs   []uint
 	ShopID        uint
 	MaxPrice      int
 	MinPrice      int
+	InStockOnly   bool
 }
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is stored hashed. Every refresh replaces the token with a new
+// one of the same family, so a used token coming back means it was stolen
+// and the whole family is revoked.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint   `gorm:"index"`
+	FamilyID  string `gorm:"type:varchar(64);index"`
+	TokenHash string `gorm:"type:varchar(64);uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+	User      User `gorm:"constraint:OnDelete:CASCADE;"`
+}
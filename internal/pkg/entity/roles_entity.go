@@ -0,0 +1,69 @@
+package entity
+
+import "gorm.io/gorm"
+
+const (
+	RoleSuperadmin = "superadmin"
+	RoleAdmin      = "admin"
+	RoleCS         = "cs"
+	RoleFinance    = "finance"
+	RoleSeller     = "seller"
+	RoleReseller   = "reseller"
+	RoleBuyer      = "buyer"
+)
+
+const (
+	PermCategoriesManage = "categories.manage"
+	PermShopsModerate    = "shops.moderate"
+	PermProductsModerate = "products.moderate"
+	PermLoginUnlock      = "auth.unlock_login"
+	PermRolesManage      = "roles.manage"
+	PermTrxReadAll       = "trx.read_all"
+	PermProductsSell     = "products.sell"
+	PermTrxCheckout      = "trx.checkout"
+)
+
+// Permissions lists every permission, in the order they are seeded.
+var Permissions = []string{
+	PermCategoriesManage,
+	PermShopsModerate,
+	PermProductsModerate,
+	PermLoginUnlock,
+	PermRolesManage,
+	PermTrxReadAll,
+	PermProductsSell,
+	PermTrxCheckout,
+}
+
+// RolePermissions is what every role may do. The migration syncs the
+// roles table to it on every start.
+var RolePermissions = map[string][]string{
+	RoleSuperadmin: Permissions,
+	RoleAdmin:      {PermCategoriesManage, PermShopsModerate, PermProductsModerate, PermLoginUnlock, PermRolesManage, PermTrxReadAll},
+	RoleCS:         {PermLoginUnlock, PermTrxReadAll},
+	RoleFinance:    {PermTrxReadAll},
+	RoleSeller:     {PermProductsSell},
+	RoleReseller:   {PermProductsSell, PermTrxCheckout},
+	RoleBuyer:      {PermTrxCheckout},
+}
+
+// DefaultUserRoles are given to every registered user, who also gets a shop.
+var DefaultUserRoles = []string{RoleBuyer, RoleSeller}
+
+type Role struct {
+	gorm.Model
+	Name        string       `gorm:"type:varchar(50);uniqueIndex"`
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE;"`
+}
+
+type Permission struct {
+	gorm.Model
+	Name string `gorm:"type:varchar(100);uniqueIndex"`
+}
+
+// UserAccess holds the names of the roles of a user and of the permissions
+// they grant.
+type UserAccess struct {
+	Roles       []string
+	Permissions []string
+}
@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShopFollow rows are deleted for real, a soft deleted row would still hold
+// the unique index and block following the shop again.
+type ShopFollow struct {
+	gorm.Model
+	UserID uint `gorm:"uniqueIndex:idx_shop_follows_user_shop"`
+	ShopID uint `gorm:"index;uniqueIndex:idx_shop_follows_user_shop"`
+	User   User `gorm:"constraint:OnDelete:CASCADE;"`
+	Shop   Shop `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+type FilterShopFollows struct {
+	Limit, Offset int
+}
+
+// FilterFeed pages through the feed by keyset: only products whose feed
+// time is before BeforeTime, or equal to it with an id below BeforeID, are
+// returned. A zero BeforeTime starts from the newest product.
+type FilterFeed struct {
+	Limit      int
+	BeforeTime time.Time
+	BeforeID   uint
+}
@@ -1,15 +1,41 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Shop struct {
 	gorm.Model
-	UserID   uint
-	ShopName string
-	PhotoURL string
+	UserID       uint
+	ShopName     string
+	Slug         string `gorm:"type:varchar(255);uniqueIndex:idx_shops_handle"`
+	PhotoURL     string
+	BannerURL    string
+	Description  string `gorm:"type:text"`
+	CityID       string
+	ContactPhone string
+	ContactEmail string
+
+	// RenamedAt is when the name or handle last changed, renames are rate
+	// limited.
+	RenamedAt *time.Time
+
+	// A suspended shop and its products are hidden and cannot be bought
+	// until an admin lifts the suspension.
+	SuspendedAt   *time.Time `gorm:"index"`
+	SuspendReason string
 }
 
 type FilterShops struct {
 	Limit, Offset int
 	ShopName      string
 }
+
+// ShopStats are computed from the products and sales of a shop.
+type ShopStats struct {
+	ProductCount  int64
+	TotalSold     int64
+	FollowerCount int64
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StockAlertLowStock   = "low_stock"
+	StockAlertOutOfStock = "out_of_stock"
+)
+
+// StockAlert is raised when a stock movement takes a product down to its
+// low stock threshold or sells it out. EmailedAt stays nil until the alert
+// was sent to the shop owner. Attempts counts the failed sends, the next one
+// is not tried before NextAttemptAt.
+type StockAlert struct {
+	gorm.Model
+	ShopID    uint `gorm:"index"`
+	ProductID uint
+	Type      string `gorm:"type:varchar(20)"`
+	Stock     int
+	Threshold int
+	EmailedAt *time.Time `gorm:"index"`
+	Shop      Shop       `gorm:"constraint:OnDelete:CASCADE;"`
+	Product   Product    `gorm:"constraint:OnDelete:CASCADE;"`
+
+	Attempts      int
+	NextAttemptAt *time.Time
+}
+
+type FilterStockAlerts struct {
+	Limit, Offset int
+	ShopID        uint
+}
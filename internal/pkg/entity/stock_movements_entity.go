@@ -0,0 +1,29 @@
+package entity
+
+import "gorm.io/gorm"
+
+const (
+	StockReasonSale       = "sale"
+	StockReasonCancel     = "cancel"
+	StockReasonAdjustment = "adjustment"
+	StockReasonImport     = "import"
+)
+
+// StockMovement is an entry of the inventory ledger. Delta is signed and
+// StockAfter is the product stock once the movement was applied.
+type StockMovement struct {
+	gorm.Model
+	ProductID         uint `gorm:"index"`
+	VariantID         *uint
+	Delta             int
+	StockAfter        int
+	VariantStockAfter *int
+	Reason            string `gorm:"type:varchar(20)"`
+	TrxID             *uint
+	ActorID           *uint
+}
+
+type FilterStockMovements struct {
+	Limit, Offset int
+	ProductID     uint
+}
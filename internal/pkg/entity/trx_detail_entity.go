@@ -6,6 +6,7 @@ type TrxDetail struct {
 	gorm.Model
 	TrxID        uint
 	ProductLogID uint
+	VariantID    *uint
 	ShopID       uint
 	Quantity     int
 	TotalPrice   int
@@ -18,15 +18,9 @@ type FilterTrx struct {
 }
 
 type ProductTrx struct {
-	Quantity      int
-	TotalPrice    int
-	ProductID     uint
-	ProductName   string
-	Slug          string
-	ResellerPrice string
-	ConsumerPrice string
-	Stock         int
-	Description   string
-	ShopID        uint
-	CategoryID    *uint
+	Quantity   int
+	TotalPrice int
+	ProductID  uint
+	ShopID     uint
+	VariantID  *uint
 }
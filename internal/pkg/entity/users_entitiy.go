@@ -18,10 +18,21 @@ type User struct {
 	Email       string `gorm:"unique"`
 	ProvinceID  string
 	CityID      string
-	IsAdmin     bool
+	IsAdmin     bool      // superseded by Roles, only read by the role backfill
 	Address     []Address `gorm:"constraint:OnDelete:CASCADE;"`
 	Shop        Shop      `gorm:"constraint:OnDelete:CASCADE;"`
 	Trx         Trx       `gorm:"constraint:OnDelete:SET NULL;"`
+
+	// TokensRevokedAt invalidates every access and refresh token issued
+	// before it, e.g. after a password change.
+	TokensRevokedAt *time.Time
+
+	// EmailVerifiedAt is nil until the user opens the link mailed to
+	// their current email.
+	EmailVerifiedAt         *time.Time
+	EmailVerificationSentAt *time.Time
+
+	Roles []Role `gorm:"many2many:user_roles;constraint:OnDelete:CASCADE;"`
 }
 
 type FilterUser struct {
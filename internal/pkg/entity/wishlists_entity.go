@@ -0,0 +1,17 @@
+package entity
+
+import "gorm.io/gorm"
+
+// Wishlist rows are deleted for real, a soft deleted row would still hold
+// the unique index and block adding the product again.
+type Wishlist struct {
+	gorm.Model
+	UserID    uint    `gorm:"uniqueIndex:idx_wishlists_user_product"`
+	ProductID uint    `gorm:"uniqueIndex:idx_wishlists_user_product"`
+	User      User    `gorm:"constraint:OnDelete:CASCADE;"`
+	Product   Product `gorm:"constraint:OnDelete:CASCADE;"`
+}
+
+type FilterWishlists struct {
+	Limit, Offset int
+}
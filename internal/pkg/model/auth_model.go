@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Login struct {
 	PhoneNumber string `json:"no_telp" validate:"required"`
 	Password    string `json:"kata_sandi" validate:"required"`
@@ -14,5 +16,50 @@ type LoginRes struct {
 	Email       string       `json:"email"`
 	ProvinceID  ProvinceResp `json:"id_provinsi"`
 	CityID      CityResp     `json:"id_kota"`
-	Token       string       `json:"token"`
+
+	EmailVerified bool     `json:"email_terverifikasi"`
+	Roles         []string `json:"peran"`
+	TokenResp
+}
+
+// TokenResp holds a short lived access token and the refresh token that
+// gets the next one. Each refresh token can be used once.
+type TokenResp struct {
+	Token          string    `json:"token"`
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	RefreshToken   string    `json:"refresh_token"`
+}
+
+// AccessResp is what the current roles of a user allow.
+type AccessResp struct {
+	Roles       []string `json:"peran"`
+	Permissions []string `json:"izin"`
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutReq ends the session of RefreshToken, or every session of the user
+// when All is set.
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required_without=All"`
+	All          bool   `json:"semua"`
+}
+
+// LoginUnlockReq lifts the login lockout of a phone number, an IP address or
+// both.
+type LoginUnlockReq struct {
+	PhoneNumber string `json:"no_telp" validate:"required_without=IP"`
+	IP          string `json:"ip" validate:"omitempty,ip"`
+}
+
+type ForgotPasswordReq struct {
+	PhoneNumber string `json:"no_telp" validate:"required"`
+}
+
+type ResetPasswordReq struct {
+	PhoneNumber string `json:"no_telp" validate:"required"`
+	OTP         string `json:"kode_otp" validate:"required,numeric"`
+	NewPassword string `json:"kata_sandi_baru" validate:"required"`
 }
@@ -3,12 +3,52 @@ package model
 type CategoryResp struct {
 	ID           uint   `json:"id"`
 	CategoryName string `json:"nama_category"`
+	ParentID     *uint  `json:"parent_id,omitempty"`
+	Slug         string `json:"slug,omitempty"`
+	Icon         string `json:"icon,omitempty"`
+	SortOrder    int    `json:"urutan,omitempty"`
+	// ProductCount includes the products of descendant categories, the
+	// same products the category filter of GET /product returns.
+	ProductCount *int64 `json:"jumlah_produk,omitempty"`
+}
+
+type CategoryTreeResp struct {
+	ID           uint               `json:"id"`
+	CategoryName string             `json:"nama_category"`
+	Slug         string             `json:"slug"`
+	Icon         string             `json:"icon"`
+	SortOrder    int                `json:"urutan"`
+	Children     []CategoryTreeResp `json:"children"`
 }
 
 type CategoryReqCreate struct {
 	CategoryName string `json:"nama_category" validate:"required"`
+	ParentID     *uint  `json:"parent_id,omitempty"`
+	Slug         string `json:"slug,omitempty"`
+	Icon         string `json:"icon,omitempty"`
+	SortOrder    int    `json:"urutan,omitempty"`
 }
 
 type CategoryReqUpdate struct {
 	CategoryName string `json:"nama_category,omitempty"`
+	Slug         string `json:"slug,omitempty"`
+	Icon         string `json:"icon,omitempty"`
+	SortOrder    *int   `json:"urutan,omitempty"`
+}
+
+// CategoryReqMove moves a category under ParentID, or to the root when
+// ParentID is null.
+type CategoryReqMove struct {
+	ParentID  *uint `json:"parent_id"`
+	SortOrder *int  `json:"urutan,omitempty"`
+}
+
+// CategoryReqDelete moves the products of the deleted category to
+// ReassignTo. Without it only a category without products can be deleted.
+type CategoryReqDelete struct {
+	ReassignTo uint `query:"reassign_to"`
+}
+
+type CategoryReqMerge struct {
+	TargetID uint `json:"target_id" validate:"required"`
 }
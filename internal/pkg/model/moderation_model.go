@@ -0,0 +1,9 @@
+package model
+
+type ModerationReqCreate struct {
+	Reason string `json:"alasan" validate:"required,max=500"`
+}
+
+type ModerationReqLift struct {
+	Reason string `json:"alasan" validate:"max=500"`
+}
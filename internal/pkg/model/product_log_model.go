@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type ProductLogResp struct {
 	ID            uint               `json:"id"`
 	ProductName   string             `json:"nama_produk"`
@@ -10,4 +12,47 @@ type ProductLogResp struct {
 	Shop          ShopInfo           `json:"toko"`
 	Category      CategoryResp       `json:"category"`
 	Images        []ProductImageResp `json:"photos"`
+	Variant       *ProductLogVariant `json:"varian,omitempty"`
+}
+
+type ProductLogVariant struct {
+	ID            uint              `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"opsi"`
+	ResellerPrice string            `json:"harga_reseler,omitempty"`
+	ConsumerPrice string            `json:"harga_konsumen,omitempty"`
+}
+
+type ProductVersionResp struct {
+	ID            uint                 `json:"id"`
+	Version       int                  `json:"versi"`
+	ProductName   string               `json:"nama_produk"`
+	Slug          string               `json:"slug"`
+	ResellerPrice string               `json:"harga_reseler"`
+	ConsumerPrice string               `json:"harga_konsumen"`
+	Description   string               `json:"deskripsi"`
+	Category      CategoryResp         `json:"category"`
+	Variants      []ProductLogVariant  `json:"varian,omitempty"`
+	Editor        *ProductEditorResp   `json:"editor"`
+	Changes       []ProductFieldChange `json:"perubahan"`
+	CreatedAt     time.Time            `json:"created_at"`
+}
+
+type ProductEditorResp struct {
+	ID   uint   `json:"id"`
+	Name string `json:"nama"`
+}
+
+// ProductFieldChange is a field that changed from one product version to
+// the next. Before is null for the first version and for added variants,
+// After is null for removed variants.
+type ProductFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"sebelum"`
+	After  interface{} `json:"sesudah"`
+}
+
+type ProductHistoryFilter struct {
+	Limit int `query:"limit"`
+	Page  int `query:"page"`
 }
@@ -1,26 +1,34 @@
 package model
 
 type ProductResp struct {
-	ID            uint               `json:"id"`
-	ProductName   string             `json:"nama_produk"`
-	Slug          string             `json:"slug"`
-	ResellerPrice string             `json:"harga_reseler"`
-	ConsumerPrice string             `json:"harga_konsumen"`
-	Stock         int                `json:"stok"`
-	Description   string             `json:"deskripsi"`
-	Shop          ShopResp           `json:"toko"`
-	Category      CategoryResp       `json:"category"`
-	Images        []ProductImageResp `json:"photos"`
+	ID            uint                 `json:"id"`
+	ProductName   string               `json:"nama_produk"`
+	Slug          string               `json:"slug"`
+	ResellerPrice string               `json:"harga_reseler"`
+	ConsumerPrice string               `json:"harga_konsumen"`
+	Stock         int                  `json:"stok"`
+	StockMinimum  int                  `json:"stok_minimum"`
+	Description   string               `json:"deskripsi"`
+	Shop          ShopResp             `json:"toko"`
+	Category      CategoryResp         `json:"category"`
+	Images        []ProductImageResp   `json:"photos"`
+	Options       []ProductOptionResp  `json:"opsi,omitempty"`
+	Variants      []ProductVariantResp `json:"varian,omitempty"`
+	IsWishlisted  bool                 `json:"is_wishlisted"`
 }
 
 type ProductsFilter struct {
 	Limit       int    `query:"limit"`
 	Page        int    `query:"page"`
 	ProductName string `query:"nama_produk"`
+	Sort        string `query:"sort" validate:"omitempty,oneof=relevance termurah termahal terbaru terlaris"`
 	CategoryID  uint   `query:"category_id"`
 	ShopID      uint   `query:"toko_id"`
 	MaxPrice    int    `query:"max_harga"`
 	MinPrice    int    `query:"min_harga"`
+	// IncludeOutOfStock lists products whose stock is zero, which are
+	// hidden by default.
+	IncludeOutOfStock bool `query:"termasuk_stok_habis"`
 }
 
 type ProductReqCreate struct {
@@ -29,8 +37,11 @@ type ProductReqCreate struct {
 	CategoryID    *uint  `form:"category_id,omitempty"`
 	ResellerPrice string `form:"harga_reseller" validate:"required"`
 	ConsumerPrice string `form:"harga_konsumen" validate:"required"`
-	Stock         int    `form:"stok" validate:"required"`
+	Stock         int    `form:"stok" validate:"required_without=Variants"`
+	StockMinimum  int    `form:"stok_minimum,omitempty" validate:"min=0"`
 	Description   string `form:"deskripsi" validate:"required"`
+	Options       string `form:"opsi,omitempty"`
+	Variants      string `form:"varian,omitempty"`
 }
 
 type ProductReqUpdate struct {
@@ -39,12 +50,59 @@ type ProductReqUpdate struct {
 	CategoryID    *uint  `form:"category_id,omitempty"`
 	ResellerPrice string `form:"harga_reseller,omitempty"`
 	ConsumerPrice string `form:"harga_konsumen,omitempty"`
-	Stock         int    `form:"stok,omitempty"`
+	Stock         *int   `form:"stok,omitempty" validate:"omitempty,min=0"`
+	StockMinimum  *int   `form:"stok_minimum,omitempty" validate:"omitempty,min=0"`
 	Description   string `form:"deskripsi,omitempty"`
+	Options       string `form:"opsi,omitempty"`
+	Variants      string `form:"varian,omitempty"`
 }
 
 type ProductImageResp struct {
-	ID        uint   `json:"id"`
-	ProductID uint   `json:"product_id"`
-	ImageURL  string `json:"url"`
+	ID         uint                 `json:"id"`
+	ProductID  uint                 `json:"product_id"`
+	ImageURL   string               `json:"url"`
+	Renditions *ImageRenditionsResp `json:"renditions,omitempty"`
+	Position   int                  `json:"posisi"`
+	IsPrimary  bool                 `json:"is_primary"`
+}
+
+type ImageRenditionsResp struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Large     string `json:"large"`
+}
+
+type ProductPhotosReorderReq struct {
+	PhotoIDs []uint `json:"urutan" validate:"required,min=1"`
+}
+
+type ProductOptionReq struct {
+	OptionName string   `json:"nama" validate:"required"`
+	Values     []string `json:"nilai" validate:"required,min=1,dive,required"`
+}
+
+type ProductVariantReq struct {
+	ID            uint              `json:"id,omitempty"`
+	SKU           string            `json:"sku" validate:"required"`
+	Options       map[string]string `json:"opsi" validate:"required"`
+	ResellerPrice string            `json:"harga_reseller" validate:"required"`
+	ConsumerPrice string            `json:"harga_konsumen" validate:"required"`
+	Stock         int               `json:"stok" validate:"min=0"`
+	PhotoIndex    *int              `json:"index_foto,omitempty"`
+}
+
+type ProductOptionResp struct {
+	ID         uint     `json:"id"`
+	OptionName string   `json:"nama"`
+	Values     []string `json:"nilai"`
+}
+
+type ProductVariantResp struct {
+	ID            uint              `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"opsi"`
+	ResellerPrice string            `json:"harga_reseler"`
+	ConsumerPrice string            `json:"harga_konsumen"`
+	Stock         int               `json:"stok"`
+	ImageURL      string            `json:"url_foto"`
 }
@@ -0,0 +1,18 @@
+package model
+
+type RoleResp struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"nama"`
+	Permissions []string `json:"izin"`
+}
+
+type UserRolesResp struct {
+	UserID uint     `json:"id_user"`
+	Roles  []string `json:"peran"`
+}
+
+// UserRolesReqUpdate replaces every role of a user. At least one is needed,
+// a user without roles would get the default ones again on the next start.
+type UserRolesReqUpdate struct {
+	Roles []string `json:"peran" validate:"required,min=1,dive,required"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+const (
+	FeedItemNew       = "baru"
+	FeedItemRestocked = "restok"
+)
+
+type ShopFollowResp struct {
+	ID         uint      `json:"id"`
+	Shop       ShopResp  `json:"toko"`
+	FollowedAt time.Time `json:"mengikuti_sejak"`
+}
+
+type ShopFollowsFilter struct {
+	Limit int `query:"limit"`
+	Page  int `query:"page"`
+}
+
+type FeedItemResp struct {
+	Type    string      `json:"tipe"`
+	Time    time.Time   `json:"waktu"`
+	Product ProductResp `json:"produk"`
+}
+
+// FeedResp is paginated by cursor rather than by page, so new products do
+// not shift the items of the next page. NextCursor is empty on the last
+// page.
+type FeedResp struct {
+	Data       []FeedItemResp `json:"data"`
+	NextCursor string         `json:"next_cursor"`
+	Limit      int            `json:"limit"`
+}
+
+type FeedFilter struct {
+	Limit  int    `query:"limit"`
+	Cursor string `query:"cursor"`
+}
@@ -1,16 +1,57 @@
 package model
 
+import "time"
+
 type MyShopResp struct {
-	ID       uint   `json:"id"`
-	ShopName string `json:"nama_toko"`
-	PhotoURL string `json:"url_foto"`
-	UserID   uint   `json:"user_id"`
+	ShopProfileResp
+	UserID uint `json:"user_id"`
 }
 
 type ShopResp struct {
-	ID       uint   `json:"id"`
-	ShopName string `json:"nama_toko"`
-	PhotoURL string `json:"url_foto"`
+	ID         uint                 `json:"id"`
+	ShopName   string               `json:"nama_toko"`
+	Slug       string               `json:"slug"`
+	PhotoURL   string               `json:"url_foto"`
+	Renditions *ImageRenditionsResp `json:"renditions_foto,omitempty"`
+	// FollowerCount is only set where shops are listed on their own, not
+	// when a shop is embedded in a product.
+	FollowerCount *int64 `json:"jumlah_pengikut,omitempty"`
+}
+
+type ShopProfileResp struct {
+	ID               uint                 `json:"id"`
+	ShopName         string               `json:"nama_toko"`
+	Slug             string               `json:"slug"`
+	PhotoURL         string               `json:"url_foto"`
+	Renditions       *ImageRenditionsResp `json:"renditions_foto,omitempty"`
+	BannerURL        string               `json:"url_banner"`
+	BannerRenditions *ImageRenditionsResp `json:"renditions_banner,omitempty"`
+	Description      string               `json:"deskripsi"`
+	City             *CityResp            `json:"kota"`
+	ContactPhone     string               `json:"no_telp"`
+	ContactEmail     string               `json:"email"`
+	JoinedAt         time.Time            `json:"bergabung_pada"`
+	Stats            ShopStatsResp        `json:"statistik"`
+}
+
+type ShopStatsResp struct {
+	ProductCount  int64 `json:"jumlah_produk"`
+	TotalSold     int64 `json:"total_terjual"`
+	FollowerCount int64 `json:"jumlah_pengikut"`
+	// Rating stays null until products can be reviewed.
+	Rating *float64 `json:"rating"`
+}
+
+type ShopStorefrontResp struct {
+	Profile          ShopProfileResp    `json:"profil"`
+	FeaturedProducts []ProductResp      `json:"produk_unggulan"`
+	Categories       []ShopCategoryResp `json:"kategori"`
+}
+
+type ShopCategoryResp struct {
+	ID           uint   `json:"id"`
+	CategoryName string `json:"nama_category"`
+	ProductCount int64  `json:"jumlah_produk"`
 }
 
 type ShopInfo struct {
@@ -19,7 +60,12 @@ type ShopInfo struct {
 }
 
 type ShopReqUpdate struct {
-	ShopName string `form:"nama_toko,omitempty"`
+	ShopName     string `form:"nama_toko,omitempty" validate:"omitempty,min=3,max=60"`
+	Handle       string `form:"handle,omitempty"`
+	Description  string `form:"deskripsi,omitempty"`
+	CityID       string `form:"city_id,omitempty"`
+	ContactPhone string `form:"no_telp,omitempty" validate:"omitempty,numeric,min=8,max=15"`
+	ContactEmail string `form:"email,omitempty" validate:"omitempty,email"`
 }
 
 type ShopsFilter struct {
@@ -27,3 +73,13 @@ type ShopsFilter struct {
 	Limit    int    `query:"limit"`
 	Page     int    `query:"page"`
 }
+
+type ShopHandleAvailabilityResp struct {
+	Handle    string `json:"handle"`
+	Available bool   `json:"tersedia"`
+	Reason    string `json:"alasan,omitempty"`
+}
+
+type ShopHandleFilter struct {
+	Handle string `query:"handle"`
+}
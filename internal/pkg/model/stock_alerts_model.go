@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+type StockAlertResp struct {
+	ID          uint       `json:"id"`
+	Type        string     `json:"tipe"`
+	ProductID   uint       `json:"product_id"`
+	ProductName string     `json:"nama_produk"`
+	Stock       int        `json:"stok"`
+	Threshold   int        `json:"stok_minimum"`
+	EmailedAt   *time.Time `json:"emailed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type StockAlertsFilter struct {
+	Limit int `query:"limit"`
+	Page  int `query:"page"`
+}
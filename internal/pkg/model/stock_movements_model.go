@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type StockMovementResp struct {
+	ID                uint      `json:"id"`
+	VariantID         *uint     `json:"varian_id,omitempty"`
+	Delta             int       `json:"perubahan"`
+	StockAfter        int       `json:"stok_akhir"`
+	VariantStockAfter *int      `json:"stok_varian_akhir,omitempty"`
+	Reason            string    `json:"alasan"`
+	TrxID             *uint     `json:"trx_id,omitempty"`
+	ActorID           *uint     `json:"user_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type StockMovementsFilter struct {
+	Limit int `query:"limit"`
+	Page  int `query:"page"`
+}
@@ -8,6 +8,7 @@ type TrxDetailResp struct {
 }
 
 type TrxDetailReqCreate struct {
-	ProductID uint `json:"product_id" validate:"required"`
-	Quantity  int  `json:"kuantitas" validate:"required"`
+	ProductID uint  `json:"product_id" validate:"required"`
+	VariantID *uint `json:"variant_id,omitempty"`
+	Quantity  int   `json:"kuantitas" validate:"required"`
 }
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type ReconcileUploadsReq struct {
+	DryRun      bool
+	GracePeriod time.Duration
+}
+
+type ReconcileUploadsResp struct {
+	Checked int      `json:"checked"`
+	Orphans []string `json:"orphans"`
+	Missing []string `json:"missing"`
+	Deleted []string `json:"deleted"`
+	// MissingRenditions are stored images lacking one of their renditions.
+	MissingRenditions []string `json:"missing_renditions"`
+}
+
+type BackfillRenditionsReq struct {
+	DryRun bool
+}
+
+type BackfillRenditionsResp struct {
+	Checked   int      `json:"checked"`
+	Missing   []string `json:"missing"`
+	Generated []string `json:"generated"`
+	Failed    []string `json:"failed"`
+}
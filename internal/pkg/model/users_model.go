@@ -11,7 +11,6 @@ type UserReqCreate struct {
 	JobTitle    string `json:"pekerjaan"`
 	ProvinceID  string `json:"id_provinsi"`
 	CityID      string `json:"id_kota"`
-	IsAdmin     bool   `json:"is_admin"`
 }
 
 type UserReqUpdate struct {
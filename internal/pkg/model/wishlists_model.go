@@ -0,0 +1,15 @@
+package model
+
+type WishlistResp struct {
+	ID      uint        `json:"id"`
+	Product ProductResp `json:"product"`
+}
+
+type WishlistReqCreate struct {
+	ProductID uint `json:"product_id" validate:"required"`
+}
+
+type WishlistsFilter struct {
+	Limit int `query:"limit"`
+	Page  int `query:"page"`
+}
@@ -13,7 +13,11 @@ type CategoriesRepository interface {
 	CreateCategory(ctx context.Context, data entity.Category) (res uint, err error)
 	GetCategories(ctx context.Context) (res []entity.Category, err error)
 	GetCategoryByID(ctx context.Context, categoryID string) (res entity.Category, err error)
+	GetTakenSlugs(ctx context.Context, base string, excludeCategoryID uint) (res []string, err error)
 	UpdateCategoryByID(ctx context.Context, categoryID string, data entity.Category) (err error)
+	MoveCategory(ctx context.Context, categoryID uint, parentID *uint, sortOrder int) (err error)
+	CountChildCategories(ctx context.Context, categoryID uint) (res int64, err error)
+	ReparentChildCategories(ctx context.Context, fromCategoryID uint, toCategoryID uint) (err error)
 	DeleteCategoryByID(ctx context.Context, categoryID string) (err error)
 
 	VerifyCategoryAvailability(ctx context.Context, categoryID string) error
@@ -41,7 +45,7 @@ func (r *CategoriesRepositoryImpl) CreateCategory(ctx context.Context, data enti
 }
 
 func (r *CategoriesRepositoryImpl) GetCategories(ctx context.Context) (res []entity.Category, err error) {
-	if err := r.tx(ctx).Find(&res).Error; err != nil {
+	if err := r.tx(ctx).Order("sort_order ASC, id ASC").Find(&res).Error; err != nil {
 		return nil, err
 	}
 
@@ -60,6 +64,17 @@ func (r *CategoriesRepositoryImpl) GetCategoryByID(ctx context.Context, category
 	return res, nil
 }
 
+func (r *CategoriesRepositoryImpl) GetTakenSlugs(ctx context.Context, base string, excludeCategoryID uint) (res []string, err error) {
+	err = r.tx(ctx).Model(&entity.Category{}).
+		Where("id <> ? AND (slug = ? OR slug LIKE ?)", excludeCategoryID, base, base+"-%").
+		Pluck("slug", &res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (r *CategoriesRepositoryImpl) UpdateCategoryByID(ctx context.Context, categoryID string, data entity.Category) (err error) {
 	if err := r.tx(ctx).Model(&entity.Category{}).Where("id = ?", categoryID).Updates(&data).Error; err != nil {
 		return err
@@ -68,6 +83,29 @@ func (r *CategoriesRepositoryImpl) UpdateCategoryByID(ctx context.Context, categ
 	return nil
 }
 
+// MoveCategory sets the parent and sort order, a nil parentID makes the
+// category a root. Unlike UpdateCategoryByID it writes nil and zero values.
+func (r *CategoriesRepositoryImpl) MoveCategory(ctx context.Context, categoryID uint, parentID *uint, sortOrder int) (err error) {
+	return r.tx(ctx).Model(&entity.Category{}).Where("id = ?", categoryID).Updates(map[string]interface{}{
+		"parent_id":  parentID,
+		"sort_order": sortOrder,
+	}).Error
+}
+
+func (r *CategoriesRepositoryImpl) CountChildCategories(ctx context.Context, categoryID uint) (res int64, err error) {
+	if err := r.tx(ctx).Model(&entity.Category{}).Where("parent_id = ?", categoryID).Count(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *CategoriesRepositoryImpl) ReparentChildCategories(ctx context.Context, fromCategoryID uint, toCategoryID uint) (err error) {
+	return r.tx(ctx).Model(&entity.Category{}).
+		Where("parent_id = ?", fromCategoryID).
+		Update("parent_id", toCategoryID).Error
+}
+
 func (r *CategoriesRepositoryImpl) DeleteCategoryByID(ctx context.Context, categoryID string) (err error) {
 	if err := r.tx(ctx).Delete(&entity.Category{}, categoryID).Error; err != nil {
 		return err
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginAttemptStore keeps the failed login counters. The memory store is
+// enough for a single instance, replicas have to share the mysql one.
+type LoginAttemptStore interface {
+	// GetLoginAttempt returns an empty attempt for keys without failures.
+	GetLoginAttempt(ctx context.Context, key string) (res entity.LoginAttempt, err error)
+	// RecordLoginFailure counts a failure of key, starting over when the
+	// previous failure is older than window.
+	RecordLoginFailure(ctx context.Context, key string, at time.Time, window time.Duration) (res entity.LoginAttempt, err error)
+	// ReleaseLoginAttempt takes back one failure of key, for an attempt
+	// that was counted before it turned out to succeed.
+	ReleaseLoginAttempt(ctx context.Context, key string) (err error)
+	LockLogin(ctx context.Context, key string, until time.Time) (err error)
+	ResetLoginAttempts(ctx context.Context, key string) (err error)
+}
+
+type MysqlLoginAttemptStore struct {
+	transactor
+}
+
+func NewMysqlLoginAttemptStore(db *gorm.DB) LoginAttemptStore {
+	return &MysqlLoginAttemptStore{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *MysqlLoginAttemptStore) GetLoginAttempt(ctx context.Context, key string) (res entity.LoginAttempt, err error) {
+	err = r.tx(ctx).Where("`key` = ?", key).First(&res).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.LoginAttempt{Key: key}, nil
+	}
+
+	return res, err
+}
+
+// RecordLoginFailure upserts in one statement, so concurrent failures from
+// several replicas are all counted.
+func (r *MysqlLoginAttemptStore) RecordLoginFailure(ctx context.Context, key string, at time.Time, window time.Duration) (res entity.LoginAttempt, err error) {
+	// failures is assigned first, it has to see the previous last_failure.
+	err = r.tx(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("IF(last_failure < ?, 1, failures + 1)", at.Add(-window))},
+			{Column: clause.Column{Name: "last_failure"}, Value: at},
+		},
+	}).Create(&entity.LoginAttempt{
+		Key:         key,
+		Failures:    1,
+		LastFailure: at,
+	}).Error
+	if err != nil {
+		return res, err
+	}
+
+	return r.GetLoginAttempt(ctx, key)
+}
+
+func (r *MysqlLoginAttemptStore) ReleaseLoginAttempt(ctx context.Context, key string) (err error) {
+	return r.tx(ctx).Model(&entity.LoginAttempt{}).
+		Where("`key` = ? AND failures > 0", key).
+		Update("failures", gorm.Expr("failures - 1")).Error
+}
+
+func (r *MysqlLoginAttemptStore) LockLogin(ctx context.Context, key string, until time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.LoginAttempt{}).Where("`key` = ?", key).Update("locked_until", until).Error
+}
+
+func (r *MysqlLoginAttemptStore) ResetLoginAttempts(ctx context.Context, key string) (err error) {
+	return r.tx(ctx).Where("`key` = ?", key).Delete(&entity.LoginAttempt{}).Error
+}
+
+// MemoryLoginAttemptStore keeps the counters in process, they are lost on
+// restart.
+type MemoryLoginAttemptStore struct {
+	mu         sync.Mutex
+	attempts   map[string]entity.LoginAttempt
+	lastPruned time.Time
+}
+
+func NewMemoryLoginAttemptStore() LoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: map[string]entity.LoginAttempt{},
+	}
+}
+
+func (r *MemoryLoginAttemptStore) GetLoginAttempt(ctx context.Context, key string) (res entity.LoginAttempt, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.attempts[key]; ok {
+		return res, nil
+	}
+
+	return entity.LoginAttempt{Key: key}, nil
+}
+
+func (r *MemoryLoginAttemptStore) RecordLoginFailure(ctx context.Context, key string, at time.Time, window time.Duration) (res entity.LoginAttempt, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(at, window)
+
+	res, ok := r.attempts[key]
+	if !ok || res.LastFailure.Before(at.Add(-window)) {
+		res.Key = key
+		res.Failures = 0
+	}
+	res.Failures++
+	res.LastFailure = at
+	r.attempts[key] = res
+
+	return res, nil
+}
+
+func (r *MemoryLoginAttemptStore) ReleaseLoginAttempt(ctx context.Context, key string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.attempts[key]; ok && res.Failures > 0 {
+		res.Failures--
+		r.attempts[key] = res
+	}
+
+	return nil
+}
+
+func (r *MemoryLoginAttemptStore) LockLogin(ctx context.Context, key string, until time.Time) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok := r.attempts[key]; ok {
+		res.LockedUntil = &until
+		r.attempts[key] = res
+	}
+
+	return nil
+}
+
+func (r *MemoryLoginAttemptStore) ResetLoginAttempts(ctx context.Context, key string) (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// prune drops counters that no longer matter, at most once per window so
+// the map does not grow with every address that ever failed a login.
+func (r *MemoryLoginAttemptStore) prune(now time.Time, window time.Duration) {
+	if now.Sub(r.lastPruned) < window {
+		return
+	}
+	r.lastPruned = now
+
+	for key, attempt := range r.attempts {
+		locked := attempt.LockedUntil != nil && attempt.LockedUntil.After(now)
+		if !locked && attempt.LastFailure.Before(now.Add(-window)) {
+			delete(r.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PasswordResetsRepository interface {
+	Transactor
+
+	CreatePasswordReset(ctx context.Context, data entity.PasswordReset) (res uint, err error)
+	GetLatestPasswordReset(ctx context.Context, userID uint) (res entity.PasswordReset, err error)
+	GetPasswordResetUsage(ctx context.Context, userID uint, since time.Time) (res entity.PasswordResetUsage, err error)
+	IncrementPasswordResetAttempts(ctx context.Context, resetID uint) (err error)
+	MarkPasswordResetsUsed(ctx context.Context, userID uint, at time.Time) (err error)
+}
+
+type PasswordResetsRepositoryImpl struct {
+	transactor
+}
+
+func NewPasswordResetsRepository(db *gorm.DB) PasswordResetsRepository {
+	return &PasswordResetsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *PasswordResetsRepositoryImpl) CreatePasswordReset(ctx context.Context, data entity.PasswordReset) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+// GetLatestPasswordReset returns the newest unused code of the user and
+// locks it, so concurrent guesses are counted one after another.
+func (r *PasswordResetsRepositoryImpl) GetLatestPasswordReset(ctx context.Context, userID uint) (res entity.PasswordReset, err error) {
+	if err := r.tx(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Order("id DESC").
+		First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetPasswordResetUsage counts the codes created for the user since since
+// and the wrong codes entered for them.
+func (r *PasswordResetsRepositoryImpl) GetPasswordResetUsage(ctx context.Context, userID uint, since time.Time) (res entity.PasswordResetUsage, err error) {
+	err = r.tx(ctx).Model(&entity.PasswordReset{}).
+		Select("COUNT(*) AS codes, COALESCE(SUM(attempts), 0) AS attempts").
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Scan(&res).Error
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *PasswordResetsRepositoryImpl) IncrementPasswordResetAttempts(ctx context.Context, resetID uint) (err error) {
+	return r.tx(ctx).Model(&entity.PasswordReset{}).
+		Where("id = ?", resetID).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// MarkPasswordResetsUsed retires every unused code of the user.
+func (r *PasswordResetsRepositoryImpl) MarkPasswordResetsUsed(ctx context.Context, userID uint, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.PasswordReset{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", at).Error
+}
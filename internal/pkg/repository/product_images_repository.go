@@ -13,6 +13,11 @@ type ProductImagesRepository interface {
 	CreateProductImage(ctx context.Context, data entity.ProductImage) (res uint, err error)
 	UpdateProductImage(ctx context.Context, imageID string, data entity.ProductImage) (err error)
 	GetImagesByProductID(ctx context.Context, productID uint) (res []entity.ProductImage, err error)
+	GetImageByID(ctx context.Context, productID uint, imageID string) (res entity.ProductImage, err error)
+	DeleteProductImage(ctx context.Context, imageID uint) (err error)
+	UpdateImagePosition(ctx context.Context, imageID uint, position int) (err error)
+	SetPrimaryImage(ctx context.Context, productID uint, imageID uint) (err error)
+	GetPhotoURLs(ctx context.Context) (res []string, err error)
 }
 
 type ProductImagesRepositoryImpl struct {
@@ -45,7 +50,7 @@ func (r *ProductImagesRepositoryImpl) UpdateProductImage(ctx context.Context, im
 }
 
 func (r *ProductImagesRepositoryImpl) GetImagesByProductID(ctx context.Context, productID uint) (res []entity.ProductImage, err error) {
-	if err := r.tx(ctx).Where("product_id = ?", productID).Find(&res).Error; err != nil {
+	if err := r.tx(ctx).Where("product_id = ?", productID).Order("position ASC, id ASC").Find(&res).Error; err != nil {
 		return nil, err
 	}
 
@@ -55,3 +60,44 @@ func (r *ProductImagesRepositoryImpl) GetImagesByProductID(ctx context.Context,
 
 	return res, nil
 }
+
+func (r *ProductImagesRepositoryImpl) GetImageByID(ctx context.Context, productID uint, imageID string) (res entity.ProductImage, err error) {
+	if err := r.tx(ctx).Where("id = ? AND product_id = ?", imageID, productID).First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductImagesRepositoryImpl) DeleteProductImage(ctx context.Context, imageID uint) (err error) {
+	if err := r.tx(ctx).Delete(&entity.ProductImage{}, imageID).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ProductImagesRepositoryImpl) UpdateImagePosition(ctx context.Context, imageID uint, position int) (err error) {
+	if err := r.tx(ctx).Model(&entity.ProductImage{}).Where("id = ?", imageID).Update("position", position).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ProductImagesRepositoryImpl) SetPrimaryImage(ctx context.Context, productID uint, imageID uint) (err error) {
+	if err := r.tx(ctx).Model(&entity.ProductImage{}).Where("product_id = ?", productID).
+		Update("is_primary", gorm.Expr("id = ?", imageID)).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ProductImagesRepositoryImpl) GetPhotoURLs(ctx context.Context) (res []string, err error) {
+	if err := r.tx(ctx).Model(&entity.ProductImage{}).Where("photo_url <> ''").Pluck("photo_url", &res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
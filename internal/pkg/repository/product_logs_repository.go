@@ -5,11 +5,14 @@ import (
 	"context"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductLogsRepository interface {
 	Transactor
 	CreateProductLogs(ctx context.Context, data entity.ProductLog) (res uint, err error)
+	GetLatestProductLog(ctx context.Context, productID uint) (res entity.ProductLog, err error)
+	GetProductLogs(ctx context.Context, params entity.FilterProductLogs) (res []entity.ProductLog, err error)
 }
 
 type ProductLogsRepositoryImpl struct {
@@ -32,3 +35,35 @@ func (r *ProductLogsRepositoryImpl) CreateProductLogs(ctx context.Context, data
 
 	return data.ID, nil
 }
+
+// GetLatestProductLog returns the newest version of a product and locks it,
+// so concurrent writers inside a transaction cannot both add the next
+// version.
+func (r *ProductLogsRepositoryImpl) GetLatestProductLog(ctx context.Context, productID uint) (res entity.ProductLog, err error) {
+	db := r.tx(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND version IS NOT NULL", productID).
+		Order("version DESC")
+
+	if err := db.First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetProductLogs returns the versions of a product, newest first. Snapshots
+// written before versioning have no version and are left out.
+func (r *ProductLogsRepositoryImpl) GetProductLogs(ctx context.Context, params entity.FilterProductLogs) (res []entity.ProductLog, err error) {
+	db := r.tx(ctx).
+		Preload("Category").
+		Preload("Editor").
+		Where("product_id = ? AND version IS NOT NULL", params.ProductID).
+		Order("version DESC")
+
+	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -2,9 +2,13 @@ package repository
 
 import (
 	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/utils"
 	"context"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductsRepository interface {
@@ -12,9 +16,23 @@ type ProductsRepository interface {
 
 	CreateProduct(ctx context.Context, data entity.Product) (res uint, err error)
 	GetAllProducts(ctx context.Context, params entity.FilterProducts) (res []entity.Product, err error)
+	GetProductsByIDs(ctx context.Context, productIDs []uint) (res []entity.Product, err error)
+	GetProductsAfterID(ctx context.Context, afterID uint, limit int) (res []entity.Product, err error)
+	GetTotalSold(ctx context.Context, productID uint) (res int, err error)
+	GetProductBySlug(ctx context.Context, shopID uint, slug string) (res entity.Product, err error)
+	GetTakenSlugs(ctx context.Context, shopID uint, base string, excludeProductID uint) (res []string, err error)
 	GetProductByID(ctx context.Context, productID string) (res entity.Product, err error)
 	UpdateProductByID(ctx context.Context, productID string, data entity.Product) (err error)
+	GetProductStock(ctx context.Context, productID uint) (res entity.Product, err error)
+	AdjustProductStock(ctx context.Context, productID uint, delta int) (res entity.Product, err error)
+	UpdateLowStockThreshold(ctx context.Context, productID uint, threshold int) (err error)
+	MarkProductRestocked(ctx context.Context, productID uint, at time.Time) (err error)
+	UpdateProductTakedown(ctx context.Context, productID uint, takenDownAt *time.Time, reason string) (err error)
 	DeleteProductByID(ctx context.Context, productID string) (err error)
+	CountProductsByCategory(ctx context.Context, shopID uint) (res map[uint]int64, err error)
+	GetProductIDsByCategory(ctx context.Context, categoryID uint) (res []uint, err error)
+	GetProductIDsByShop(ctx context.Context, shopID uint) (res []uint, err error)
+	ReassignProductsCategory(ctx context.Context, fromCategoryID uint, toCategoryID uint) (err error)
 
 	VerifyProductAvailability(ctx context.Context, productID string) (err error)
 	VerifyProductOwner(ctx context.Context, productID string, shopID string) (err error)
@@ -45,33 +63,77 @@ func (r *ProductsRepositoryImpl) GetAllProducts(ctx context.Context, params enti
 	db := r.tx(ctx).
 		Preload("Shop").
 		Preload("Category").
-		Preload("Images")
+		Preload("Images", orderedImages)
 
-	if params.ProductName != "" {
-		db = db.Where("product_name LIKE ?", "%"+params.ProductName+"%")
+	db = applyProductFilters(db, params)
+
+	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
+		return nil, err
 	}
-	if params.CategoryID > 0 {
-		db = db.Where("category_id LIKE ?", params.CategoryID)
+
+	return res, nil
+}
+
+// GetProductsByIDs returns the visible products among productIDs in the
+// order of productIDs, so a stale search index cannot list moderated ones.
+func (r *ProductsRepositoryImpl) GetProductsByIDs(ctx context.Context, productIDs []uint) (res []entity.Product, err error) {
+	if len(productIDs) == 0 {
+		return res, nil
 	}
-	if params.ShopID > 0 {
-		db = db.Where("shop_id LIKE ?", params.ShopID)
+
+	var products []entity.Product
+	db := r.tx(ctx).
+		Preload("Shop").
+		Preload("Category").
+		Preload("Images", orderedImages).
+		Scopes(visibleProducts)
+
+	if err := db.Where("products.id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, err
 	}
-	if params.MinPrice > 0 {
-		db = db.Where("consumer_price >= ?", params.MinPrice)
+
+	byID := make(map[uint]entity.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
 	}
-	if params.MaxPrice > 0 {
-		db = db.Where("consumer_price <= ?", params.MaxPrice)
+
+	for _, id := range productIDs {
+		if product, ok := byID[id]; ok {
+			res = append(res, product)
+		}
 	}
 
-	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
+	return res, nil
+}
+
+func (r *ProductsRepositoryImpl) GetProductsAfterID(ctx context.Context, afterID uint, limit int) (res []entity.Product, err error) {
+	if err := r.tx(ctx).Preload("Shop").Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&res).Error; err != nil {
 		return nil, err
 	}
 
 	return res, nil
 }
 
+func (r *ProductsRepositoryImpl) GetTotalSold(ctx context.Context, productID uint) (res int, err error) {
+	if err := r.tx(ctx).Table("trx_details").
+		Select("COALESCE(SUM(trx_details.quantity), 0)").
+		Joins("JOIN product_logs ON product_logs.id = trx_details.product_log_id").
+		Where("product_logs.product_id = ? AND trx_details.deleted_at IS NULL", productID).
+		Scan(&res).Error; err != nil {
+		return 0, err
+	}
+
+	return res, nil
+}
+
 func (r *ProductsRepositoryImpl) GetProductByID(ctx context.Context, productID string) (res entity.Product, err error) {
-	db := r.tx(ctx).Preload("Shop").Preload("Category").Preload("Images")
+	db := r.tx(ctx).
+		Preload("Shop").
+		Preload("Category").
+		Preload("Images", orderedImages).
+		Preload("Options").
+		Preload("Variants")
+
 	if err := db.First(&res, productID).Error; err != nil {
 		return res, err
 	}
@@ -79,6 +141,38 @@ func (r *ProductsRepositoryImpl) GetProductByID(ctx context.Context, productID s
 	return res, nil
 }
 
+func (r *ProductsRepositoryImpl) GetProductBySlug(ctx context.Context, shopID uint, slug string) (res entity.Product, err error) {
+	db := r.tx(ctx).
+		Preload("Shop").
+		Preload("Category").
+		Preload("Images", orderedImages).
+		Preload("Options").
+		Preload("Variants")
+
+	if err := db.Where("shop_id = ? AND slug = ?", shopID, slug).First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetTakenSlugs lists slugs equal to base or base with a suffix that are used
+// by other products of the shop, including deleted products and old slugs
+// kept as redirects.
+func (r *ProductsRepositoryImpl) GetTakenSlugs(ctx context.Context, shopID uint, base string, excludeProductID uint) (res []string, err error) {
+	query := `SELECT slug FROM products WHERE shop_id = ? AND id <> ? AND (slug = ? OR slug LIKE ?)
+		UNION SELECT slug FROM product_slug_redirects WHERE shop_id = ? AND product_id <> ? AND deleted_at IS NULL AND (slug = ? OR slug LIKE ?)`
+
+	if err := r.tx(ctx).Raw(query,
+		shopID, excludeProductID, base, base+"-%",
+		shopID, excludeProductID, base, base+"-%",
+	).Scan(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (r *ProductsRepositoryImpl) UpdateProductByID(ctx context.Context, productID string, data entity.Product) (err error) {
 	if err := r.tx(ctx).Model(&entity.Product{}).Where("id = ?", productID).Updates(&data).Error; err != nil {
 		return err
@@ -87,6 +181,59 @@ func (r *ProductsRepositoryImpl) UpdateProductByID(ctx context.Context, productI
 	return nil
 }
 
+// GetProductStock returns only the columns needed to act on stock changes:
+// id, shop_id, product_name, stock and low_stock_threshold.
+func (r *ProductsRepositoryImpl) GetProductStock(ctx context.Context, productID uint) (res entity.Product, err error) {
+	err = r.tx(ctx).
+		Select("id", "shop_id", "product_name", "stock", "low_stock_threshold").
+		Where("id = ?", productID).
+		First(&res).Error
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// AdjustProductStock adds delta to the stock in a single statement and
+// returns the product as GetProductStock does. It fails with
+// ErrInsufficientStock instead of letting the stock drop below zero.
+func (r *ProductsRepositoryImpl) AdjustProductStock(ctx context.Context, productID uint, delta int) (res entity.Product, err error) {
+	result := r.tx(ctx).Model(&entity.Product{}).
+		Where("id = ? AND stock + ? >= 0", productID, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return res, ErrInsufficientStock
+	}
+
+	return r.GetProductStock(ctx, productID)
+}
+
+// UpdateLowStockThreshold is separate from UpdateProductByID, which skips
+// zero values and so could not turn the threshold off.
+func (r *ProductsRepositoryImpl) UpdateLowStockThreshold(ctx context.Context, productID uint, threshold int) (err error) {
+	return r.tx(ctx).Model(&entity.Product{}).Where("id = ?", productID).Update("low_stock_threshold", threshold).Error
+}
+
+// MarkProductRestocked records that the stock went from zero back up, which
+// puts the product back at the top of the feed of the shop followers.
+func (r *ProductsRepositoryImpl) MarkProductRestocked(ctx context.Context, productID uint, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.Product{}).Where("id = ?", productID).Update("restocked_at", at).Error
+}
+
+// UpdateProductTakedown takes the product down, or restores it when
+// takenDownAt is nil.
+func (r *ProductsRepositoryImpl) UpdateProductTakedown(ctx context.Context, productID uint, takenDownAt *time.Time, reason string) (err error) {
+	return r.tx(ctx).Model(&entity.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"taken_down_at":   takenDownAt,
+		"takedown_reason": reason,
+	}).Error
+}
+
 func (r *ProductsRepositoryImpl) DeleteProductByID(ctx context.Context, productID string) (err error) {
 	if err := r.tx(ctx).Delete(&entity.Product{}, productID).Error; err != nil {
 		return err
@@ -95,6 +242,65 @@ func (r *ProductsRepositoryImpl) DeleteProductByID(ctx context.Context, productI
 	return nil
 }
 
+// CountProductsByCategory returns the number of products in each category
+// that GET /product lists by default, visible and in stock, counted in a
+// single grouped query. A non zero shopID only counts the products of that
+// shop.
+func (r *ProductsRepositoryImpl) CountProductsByCategory(ctx context.Context, shopID uint) (res map[uint]int64, err error) {
+	var rows []struct {
+		CategoryID uint
+		Total      int64
+	}
+
+	db := r.tx(ctx).Model(&entity.Product{}).
+		Select("category_id, COUNT(*) AS total").
+		Scopes(visibleProducts).
+		Where("category_id IS NOT NULL AND stock > 0")
+	if shopID > 0 {
+		db = db.Where("shop_id = ?", shopID)
+	}
+
+	err = db.Group("category_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	res = make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		res[row.CategoryID] = row.Total
+	}
+
+	return res, nil
+}
+
+// GetProductIDsByCategory returns the products of a category and locks
+// them, so inside a transaction they cannot change category until it ends.
+func (r *ProductsRepositoryImpl) GetProductIDsByCategory(ctx context.Context, categoryID uint) (res []uint, err error) {
+	db := r.tx(ctx).Model(&entity.Product{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("category_id = ?", categoryID)
+
+	if err := db.Pluck("id", &res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductsRepositoryImpl) GetProductIDsByShop(ctx context.Context, shopID uint) (res []uint, err error) {
+	if err := r.tx(ctx).Model(&entity.Product{}).Where("shop_id = ?", shopID).Pluck("id", &res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductsRepositoryImpl) ReassignProductsCategory(ctx context.Context, fromCategoryID uint, toCategoryID uint) (err error) {
+	return r.tx(ctx).Model(&entity.Product{}).
+		Where("category_id = ?", fromCategoryID).
+		Update("category_id", toCategoryID).Error
+}
+
 func (r *ProductsRepositoryImpl) VerifyProductAvailability(ctx context.Context, productID string) (err error) {
 	var product entity.Product
 	if err := r.tx(ctx).Where("id = ? ", productID).First(&product).Error; err != nil {
@@ -112,3 +318,63 @@ func (r *ProductsRepositoryImpl) VerifyProductOwner(ctx context.Context, product
 
 	return nil
 }
+
+// applyProductFilters applies the FilterProducts conditions and ordering shared
+// by product listing and the MySQL search index. SearchQuery holds normalized,
+// space separated terms.
+func applyProductFilters(db *gorm.DB, params entity.FilterProducts) *gorm.DB {
+	db = db.Scopes(visibleProducts)
+
+	fulltext := utils.BuildFulltextQuery(strings.Fields(params.SearchQuery))
+	if fulltext != "" {
+		db = db.Where("MATCH(product_name, description) AGAINST (? IN BOOLEAN MODE)", fulltext)
+	}
+	if len(params.CategoryIDs) > 0 {
+		db = db.Where("category_id IN ?", params.CategoryIDs)
+	}
+	if params.ShopID > 0 {
+		db = db.Where("shop_id LIKE ?", params.ShopID)
+	}
+	if params.MinPrice > 0 {
+		db = db.Where("consumer_price >= ?", params.MinPrice)
+	}
+	if params.MaxPrice > 0 {
+		db = db.Where("consumer_price <= ?", params.MaxPrice)
+	}
+	if params.InStockOnly {
+		db = db.Where("stock > 0")
+	}
+
+	switch params.Sort {
+	case entity.ProductSortCheapest:
+		db = db.Order("CAST(consumer_price AS UNSIGNED) ASC")
+	case entity.ProductSortPriciest:
+		db = db.Order("CAST(consumer_price AS UNSIGNED) DESC")
+	case entity.ProductSortNewest:
+		db = db.Order("created_at DESC")
+	case entity.ProductSortBestSell:
+		db = db.Order(`(SELECT COALESCE(SUM(trx_details.quantity), 0) FROM trx_details
+			JOIN product_logs ON product_logs.id = trx_details.product_log_id
+			WHERE product_logs.product_id = products.id AND trx_details.deleted_at IS NULL) DESC`)
+	default:
+		if fulltext != "" {
+			db = db.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                "MATCH(product_name, description) AGAINST (? IN BOOLEAN MODE) DESC",
+				Vars:               []interface{}{fulltext},
+				WithoutParentheses: true,
+			}})
+		}
+	}
+
+	return db
+}
+
+// visibleProducts leaves out products taken down by an admin and the
+// products of suspended shops.
+func visibleProducts(db *gorm.DB) *gorm.DB {
+	return db.Where("products.taken_down_at IS NULL AND products.shop_id NOT IN (SELECT id FROM shops WHERE suspended_at IS NOT NULL)")
+}
+
+func orderedImages(db *gorm.DB) *gorm.DB {
+	return db.Order("position ASC, id ASC")
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"backend-evermos/internal/infrastructure/restclient"
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ProductSearchIndex interface {
+	IndexProducts(ctx context.Context, docs []entity.ProductSearchDocument) (err error)
+	DeleteProduct(ctx context.Context, productID uint) (err error)
+	SearchProductIDs(ctx context.Context, params entity.FilterProducts) (res []uint, err error)
+	ResetIndex(ctx context.Context) (err error)
+}
+
+// MysqlProductSearchIndex answers searches straight from the products table
+// using the FULLTEXT index, so there is nothing to keep in sync.
+type MysqlProductSearchIndex struct {
+	transactor
+}
+
+func NewMysqlProductSearchIndex(db *gorm.DB) ProductSearchIndex {
+	return &MysqlProductSearchIndex{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *MysqlProductSearchIndex) IndexProducts(ctx context.Context, docs []entity.ProductSearchDocument) (err error) {
+	return nil
+}
+
+func (r *MysqlProductSearchIndex) DeleteProduct(ctx context.Context, productID uint) (err error) {
+	return nil
+}
+
+func (r *MysqlProductSearchIndex) SearchProductIDs(ctx context.Context, params entity.FilterProducts) (res []uint, err error) {
+	db := applyProductFilters(r.tx(ctx).Model(&entity.Product{}), params)
+	if err := db.Limit(params.Limit).Offset(params.Offset).Pluck("id", &res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *MysqlProductSearchIndex) ResetIndex(ctx context.Context) (err error) {
+	return nil
+}
+
+// MeilisearchProductIndex keeps product documents in a Meilisearch index over
+// its HTTP API.
+type MeilisearchProductIndex struct {
+	client *restclient.RestClient
+	host   string
+	apiKey string
+	index  string
+}
+
+func NewMeilisearchProductIndex(client *restclient.RestClient, host string, apiKey string, index string) ProductSearchIndex {
+	return &MeilisearchProductIndex{
+		client: client,
+		host:   strings.TrimRight(host, "/"),
+		apiKey: apiKey,
+		index:  index,
+	}
+}
+
+type meilisearchSearchReq struct {
+	Q                    string   `json:"q"`
+	Filter               []string `json:"filter,omitempty"`
+	Sort                 []string `json:"sort,omitempty"`
+	Limit                int      `json:"limit"`
+	Offset               int      `json:"offset"`
+	AttributesToRetrieve []string `json:"attributesToRetrieve"`
+}
+
+type meilisearchSearchResp struct {
+	Hits []struct {
+		ID uint `json:"id"`
+	} `json:"hits"`
+}
+
+func (r *MeilisearchProductIndex) IndexProducts(ctx context.Context, docs []entity.ProductSearchDocument) (err error) {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	url := fmt.Sprintf("%s/indexes/%s/documents?primaryKey=id", r.host, r.index)
+	return r.client.Do(http.MethodPost, url, r.headers(), docs, nil)
+}
+
+func (r *MeilisearchProductIndex) DeleteProduct(ctx context.Context, productID uint) (err error) {
+	url := fmt.Sprintf("%s/indexes/%s/documents/%d", r.host, r.index, productID)
+	return r.client.Do(http.MethodDelete, url, r.headers(), nil, nil)
+}
+
+func (r *MeilisearchProductIndex) SearchProductIDs(ctx context.Context, params entity.FilterProducts) (res []uint, err error) {
+	req := meilisearchSearchReq{
+		Q:                    params.SearchQuery,
+		Limit:                params.Limit,
+		Offset:               params.Offset,
+		AttributesToRetrieve: []string{"id"},
+	}
+
+	if len(params.CategoryIDs) > 0 {
+		ids := make([]string, 0, len(params.CategoryIDs))
+		for _, id := range params.CategoryIDs {
+			ids = append(ids, fmt.Sprintf("%d", id))
+		}
+		req.Filter = append(req.Filter, fmt.Sprintf("category_id IN [%s]", strings.Join(ids, ", ")))
+	}
+	if params.ShopID > 0 {
+		req.Filter = append(req.Filter, fmt.Sprintf("shop_id = %d", params.ShopID))
+	}
+	if params.MinPrice > 0 {
+		req.Filter = append(req.Filter, fmt.Sprintf("consumer_price >= %d", params.MinPrice))
+	}
+	if params.MaxPrice > 0 {
+		req.Filter = append(req.Filter, fmt.Sprintf("consumer_price <= %d", params.MaxPrice))
+	}
+	if params.InStockOnly {
+		req.Filter = append(req.Filter, "stock > 0")
+	}
+
+	switch params.Sort {
+	case entity.ProductSortCheapest:
+		req.Sort = []string{"consumer_price:asc"}
+	case entity.ProductSortPriciest:
+		req.Sort = []string{"consumer_price:desc"}
+	case entity.ProductSortNewest:
+		req.Sort = []string{"created_at:desc"}
+	case entity.ProductSortBestSell:
+		req.Sort = []string{"total_sold:desc"}
+	}
+
+	var resp meilisearchSearchResp
+	url := fmt.Sprintf("%s/indexes/%s/search", r.host, r.index)
+	if err := r.client.Do(http.MethodPost, url, r.headers(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	for _, hit := range resp.Hits {
+		res = append(res, hit.ID)
+	}
+
+	return res, nil
+}
+
+func (r *MeilisearchProductIndex) ResetIndex(ctx context.Context) (err error) {
+	settings := map[string]interface{}{
+		"searchableAttributes": []string{"product_name", "description"},
+		"filterableAttributes": []string{"category_id", "shop_id", "consumer_price", "stock"},
+		"sortableAttributes":   []string{"consumer_price", "created_at", "total_sold"},
+	}
+
+	url := fmt.Sprintf("%s/indexes/%s/settings", r.host, r.index)
+	if err := r.client.Do(http.MethodPatch, url, r.headers(), settings, nil); err != nil {
+		return err
+	}
+
+	url = fmt.Sprintf("%s/indexes/%s/documents", r.host, r.index)
+	return r.client.Do(http.MethodDelete, url, r.headers(), nil, nil)
+}
+
+func (r *MeilisearchProductIndex) headers() map[string]string {
+	if r.apiKey == "" {
+		return nil
+	}
+
+	return map[string]string{"Authorization": "Bearer " + r.apiKey}
+}
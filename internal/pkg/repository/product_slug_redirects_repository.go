@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ProductSlugRedirectsRepository interface {
+	Transactor
+
+	CreateSlugRedirect(ctx context.Context, data entity.ProductSlugRedirect) (res uint, err error)
+	GetSlugRedirect(ctx context.Context, shopID uint, slug string) (res entity.ProductSlugRedirect, err error)
+	DeleteSlugRedirect(ctx context.Context, productID uint, slug string) (err error)
+}
+
+type ProductSlugRedirectsRepositoryImpl struct {
+	transactor
+}
+
+func NewProductSlugRedirectsRepository(db *gorm.DB) ProductSlugRedirectsRepository {
+	return &ProductSlugRedirectsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *ProductSlugRedirectsRepositoryImpl) CreateSlugRedirect(ctx context.Context, data entity.ProductSlugRedirect) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+func (r *ProductSlugRedirectsRepositoryImpl) GetSlugRedirect(ctx context.Context, shopID uint, slug string) (res entity.ProductSlugRedirect, err error) {
+	if err := r.tx(ctx).Preload("Product").Where("shop_id = ? AND slug = ?", shopID, slug).First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductSlugRedirectsRepositoryImpl) DeleteSlugRedirect(ctx context.Context, productID uint, slug string) (err error) {
+	if err := r.tx(ctx).Where("product_id = ? AND slug = ?", productID, slug).Delete(&entity.ProductSlugRedirect{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
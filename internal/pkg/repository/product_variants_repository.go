@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type ProductVariantsRepository interface {
+	Transactor
+
+	ReplaceProductOptions(ctx context.Context, productID uint, data []entity.ProductOption) (err error)
+	CreateProductVariant(ctx context.Context, data entity.ProductVariant) (res uint, err error)
+	UpdateProductVariant(ctx context.Context, variantID uint, data entity.ProductVariant) (err error)
+	AdjustVariantStock(ctx context.Context, variantID uint, delta int) (res int, err error)
+	GetVariantsByProductID(ctx context.Context, productID uint) (res []entity.ProductVariant, err error)
+	GetVariantByID(ctx context.Context, productID uint, variantID uint) (res entity.ProductVariant, err error)
+	DeleteVariantsExcept(ctx context.Context, productID uint, keepIDs []uint) (err error)
+	GetPhotoURLs(ctx context.Context) (res []string, err error)
+}
+
+type ProductVariantsRepositoryImpl struct {
+	transactor
+}
+
+func NewProductVariantsRepository(db *gorm.DB) ProductVariantsRepository {
+	return &ProductVariantsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *ProductVariantsRepositoryImpl) ReplaceProductOptions(ctx context.Context, productID uint, data []entity.ProductOption) (err error) {
+	if err := r.tx(ctx).Where("product_id = ?", productID).Delete(&entity.ProductOption{}).Error; err != nil {
+		return err
+	}
+
+	for i := range data {
+		data[i].ProductID = productID
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+
+	return r.tx(ctx).Create(&data).Error
+}
+
+func (r *ProductVariantsRepositoryImpl) CreateProductVariant(ctx context.Context, data entity.ProductVariant) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+func (r *ProductVariantsRepositoryImpl) UpdateProductVariant(ctx context.Context, variantID uint, data entity.ProductVariant) (err error) {
+	if err := r.tx(ctx).Model(&entity.ProductVariant{}).Where("id = ?", variantID).Updates(&data).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AdjustVariantStock adds delta to the stock of a variant the same way
+// AdjustProductStock does for a product.
+func (r *ProductVariantsRepositoryImpl) AdjustVariantStock(ctx context.Context, variantID uint, delta int) (res int, err error) {
+	result := r.tx(ctx).Model(&entity.ProductVariant{}).
+		Where("id = ? AND stock + ? >= 0", variantID, delta).
+		Update("stock", gorm.Expr("stock + ?", delta))
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return res, ErrInsufficientStock
+	}
+
+	var variant entity.ProductVariant
+	if err := r.tx(ctx).Select("stock").Where("id = ?", variantID).First(&variant).Error; err != nil {
+		return res, err
+	}
+
+	return variant.Stock, nil
+}
+
+func (r *ProductVariantsRepositoryImpl) GetVariantsByProductID(ctx context.Context, productID uint) (res []entity.ProductVariant, err error) {
+	if err := r.tx(ctx).Where("product_id = ?", productID).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductVariantsRepositoryImpl) GetVariantByID(ctx context.Context, productID uint, variantID uint) (res entity.ProductVariant, err error) {
+	if err := r.tx(ctx).Where("id = ? AND product_id = ?", variantID, productID).First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *ProductVariantsRepositoryImpl) DeleteVariantsExcept(ctx context.Context, productID uint, keepIDs []uint) (err error) {
+	db := r.tx(ctx).Where("product_id = ?", productID)
+	if len(keepIDs) > 0 {
+		db = db.Where("id NOT IN ?", keepIDs)
+	}
+
+	if err := db.Delete(&entity.ProductVariant{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ProductVariantsRepositoryImpl) GetPhotoURLs(ctx context.Context) (res []string, err error) {
+	if err := r.tx(ctx).Model(&entity.ProductVariant{}).Where("photo_url <> ''").Pluck("photo_url", &res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefreshTokensRepository interface {
+	Transactor
+
+	CreateRefreshToken(ctx context.Context, data entity.RefreshToken) (res uint, err error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (res entity.RefreshToken, err error)
+	MarkRefreshTokenUsed(ctx context.Context, tokenID uint, at time.Time) (err error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) (err error)
+}
+
+type RefreshTokensRepositoryImpl struct {
+	transactor
+}
+
+func NewRefreshTokensRepository(db *gorm.DB) RefreshTokensRepository {
+	return &RefreshTokensRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *RefreshTokensRepositoryImpl) CreateRefreshToken(ctx context.Context, data entity.RefreshToken) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+// GetRefreshTokenByHash locks the token row, so two concurrent refreshes
+// with the same token cannot both rotate it.
+func (r *RefreshTokensRepositoryImpl) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (res entity.RefreshToken, err error) {
+	if err := r.tx(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *RefreshTokensRepositoryImpl) MarkRefreshTokenUsed(ctx context.Context, tokenID uint, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.RefreshToken{}).Where("id = ?", tokenID).Update("used_at", at).Error
+}
+
+func (r *RefreshTokensRepositoryImpl) RevokeRefreshTokenFamily(ctx context.Context, familyID string, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
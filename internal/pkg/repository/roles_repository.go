@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type RolesRepository interface {
+	Transactor
+
+	GetRoles(ctx context.Context) (res []entity.Role, err error)
+	GetRolesByNames(ctx context.Context, names []string) (res []entity.Role, err error)
+	GetUserRoleNames(ctx context.Context, userID string) (res []string, err error)
+	GetUserAccess(ctx context.Context, userID string) (res entity.UserAccess, err error)
+	ReplaceUserRoles(ctx context.Context, userID uint, roles []entity.Role) (err error)
+	AddUserRoles(ctx context.Context, userID uint, roles []entity.Role) (err error)
+}
+
+type RolesRepositoryImpl struct {
+	transactor
+}
+
+func NewRolesRepository(db *gorm.DB) RolesRepository {
+	return &RolesRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *RolesRepositoryImpl) GetRoles(ctx context.Context) (res []entity.Role, err error) {
+	if err := r.tx(ctx).Preload("Permissions").Order("id").Find(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *RolesRepositoryImpl) GetRolesByNames(ctx context.Context, names []string) (res []entity.Role, err error) {
+	if err := r.tx(ctx).Where("name IN ?", names).Find(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func (r *RolesRepositoryImpl) GetUserRoleNames(ctx context.Context, userID string) (res []string, err error) {
+	err = r.tx(ctx).Model(&entity.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
+		Pluck("roles.name", &res).Error
+
+	return res, err
+}
+
+// GetUserAccess returns the roles of a user and what they allow in a single
+// query, which yields a row per role and permission pair.
+func (r *RolesRepositoryImpl) GetUserAccess(ctx context.Context, userID string) (res entity.UserAccess, err error) {
+	var rows []struct {
+		Role       string
+		Permission *string
+	}
+
+	err = r.tx(ctx).Model(&entity.Role{}).
+		Select("roles.name AS role, permissions.name AS permission").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Joins("LEFT JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Joins("LEFT JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id, permissions.id").
+		Scan(&rows).Error
+	if err != nil {
+		return res, err
+	}
+
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if !seen["role:"+row.Role] {
+			seen["role:"+row.Role] = true
+			res.Roles = append(res.Roles, row.Role)
+		}
+
+		if row.Permission != nil && !seen["permission:"+*row.Permission] {
+			seen["permission:"+*row.Permission] = true
+			res.Permissions = append(res.Permissions, *row.Permission)
+		}
+	}
+
+	return res, nil
+}
+
+func (r *RolesRepositoryImpl) ReplaceUserRoles(ctx context.Context, userID uint, roles []entity.Role) (err error) {
+	user := entity.User{Model: gorm.Model{ID: userID}}
+	return r.tx(ctx).Model(&user).Association("Roles").Replace(roles)
+}
+
+func (r *RolesRepositoryImpl) AddUserRoles(ctx context.Context, userID uint, roles []entity.Role) (err error) {
+	user := entity.User{Model: gorm.Model{ID: userID}}
+	return r.tx(ctx).Model(&user).Association("Roles").Append(roles)
+}
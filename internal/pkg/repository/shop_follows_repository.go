@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// feedTimeColumn is when a product last appeared in the feed: when it was
+// restocked, or else when it was created.
+const feedTimeColumn = "COALESCE(products.restocked_at, products.created_at)"
+
+type ShopFollowsRepository interface {
+	Transactor
+
+	CreateShopFollow(ctx context.Context, data entity.ShopFollow) (res uint, err error)
+	GetShopFollowsByUserID(ctx context.Context, userID string, params entity.FilterShopFollows) (res []entity.ShopFollow, err error)
+	GetFeedProducts(ctx context.Context, userID string, params entity.FilterFeed) (res []entity.Product, err error)
+	DeleteShopFollow(ctx context.Context, userID string, shopID string) (err error)
+
+	VerifyShopFollowAvailability(ctx context.Context, userID string, shopID string) (err error)
+}
+
+type ShopFollowsRepositoryImpl struct {
+	transactor
+}
+
+func NewShopFollowsRepository(db *gorm.DB) ShopFollowsRepository {
+	return &ShopFollowsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *ShopFollowsRepositoryImpl) CreateShopFollow(ctx context.Context, data entity.ShopFollow) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+func (r *ShopFollowsRepositoryImpl) GetShopFollowsByUserID(ctx context.Context, userID string, params entity.FilterShopFollows) (res []entity.ShopFollow, err error) {
+	db := r.tx(ctx).
+		Joins("JOIN shops ON shops.id = shop_follows.shop_id AND shops.deleted_at IS NULL").
+		Preload("Shop")
+
+	if err := db.Where("shop_follows.user_id = ?", userID).
+		Order("shop_follows.created_at DESC").
+		Limit(params.Limit).Offset(params.Offset).
+		Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetFeedProducts returns the in stock products of the shops userID follows,
+// the most recently created or restocked first.
+func (r *ShopFollowsRepositoryImpl) GetFeedProducts(ctx context.Context, userID string, params entity.FilterFeed) (res []entity.Product, err error) {
+	db := r.tx(ctx).
+		Joins("JOIN shop_follows ON shop_follows.shop_id = products.shop_id AND shop_follows.deleted_at IS NULL").
+		Where("shop_follows.user_id = ? AND products.stock > 0", userID).
+		Scopes(visibleProducts)
+
+	if !params.BeforeTime.IsZero() {
+		db = db.Where(
+			feedTimeColumn+" < ? OR ("+feedTimeColumn+" = ? AND products.id < ?)",
+			params.BeforeTime, params.BeforeTime, params.BeforeID,
+		)
+	}
+
+	if err := db.
+		Preload("Shop").
+		Preload("Category").
+		Preload("Images", orderedImages).
+		Order(feedTimeColumn + " DESC").
+		Order("products.id DESC").
+		Limit(params.Limit).
+		Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *ShopFollowsRepositoryImpl) DeleteShopFollow(ctx context.Context, userID string, shopID string) (err error) {
+	result := r.tx(ctx).Unscoped().Where("user_id = ? AND shop_id = ?", userID, shopID).Delete(&entity.ShopFollow{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *ShopFollowsRepositoryImpl) VerifyShopFollowAvailability(ctx context.Context, userID string, shopID string) (err error) {
+	var follow entity.ShopFollow
+	if err := r.tx(ctx).Where("user_id = ? AND shop_id = ?", userID, shopID).First(&follow).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
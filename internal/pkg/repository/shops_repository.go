@@ -3,6 +3,7 @@ package repository
 import (
 	"backend-evermos/internal/pkg/entity"
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +16,13 @@ type ShopsRepository interface {
 	UpdateShopByID(ctx context.Context, shopID string, data entity.Shop) (err error)
 	GetShopByID(ctx context.Context, shopID string) (res entity.Shop, err error)
 	GetAllShops(ctx context.Context, params entity.FilterShops) (res []entity.Shop, err error)
+	GetShopBySlug(ctx context.Context, slug string) (res entity.Shop, err error)
+	GetTakenSlugs(ctx context.Context, base string) (res []string, err error)
+	IsShopHandleTaken(ctx context.Context, handle string, excludeShopID uint) (res bool, err error)
+	GetPhotoURLs(ctx context.Context) (res []string, err error)
+	GetShopStats(ctx context.Context, shopID uint) (res entity.ShopStats, err error)
+	CountShopFollowers(ctx context.Context, shopIDs []uint) (res map[uint]int64, err error)
+	UpdateShopSuspension(ctx context.Context, shopID uint, suspendedAt *time.Time, reason string) (err error)
 
 	VerifyShopAvailability(ctx context.Context, shopID string) error
 	VerifyShopOwner(ctx context.Context, shopID string, userID string) error
@@ -69,7 +77,7 @@ func (r *ShopsRepositoryImpl) GetAllShops(ctx context.Context, params entity.Fil
 	db := r.tx(ctx)
 
 	keyword := "%" + params.ShopName + "%"
-	db = db.Where("shop_name LIKE ?", keyword)
+	db = db.Where("shop_name LIKE ? AND suspended_at IS NULL", keyword)
 
 	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
 		return nil, err
@@ -82,6 +90,40 @@ func (r *ShopsRepositoryImpl) GetAllShops(ctx context.Context, params entity.Fil
 	return res, nil
 }
 
+func (r *ShopsRepositoryImpl) GetShopBySlug(ctx context.Context, slug string) (res entity.Shop, err error) {
+	if err := r.tx(ctx).Where("slug = ?", slug).First(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// GetTakenSlugs lists the handles equal to base or base with a suffix,
+// deleted shops included as IsShopHandleTaken does, since their handle
+// still holds the unique index.
+func (r *ShopsRepositoryImpl) GetTakenSlugs(ctx context.Context, base string) (res []string, err error) {
+	if err := r.tx(ctx).Unscoped().Model(&entity.Shop{}).
+		Where("slug = ? OR slug LIKE ?", base, base+"-%").
+		Pluck("slug", &res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// IsShopHandleTaken reports whether another shop, deleted shops included,
+// uses handle.
+func (r *ShopsRepositoryImpl) IsShopHandleTaken(ctx context.Context, handle string, excludeShopID uint) (res bool, err error) {
+	var count int64
+	if err := r.tx(ctx).Unscoped().Model(&entity.Shop{}).
+		Where("slug = ? AND id <> ?", handle, excludeShopID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
 func (r *ShopsRepositoryImpl) VerifyShopAvailability(ctx context.Context, shopID string) error {
 	var shop entity.Shop
 	if err := r.tx(ctx).Where("id = ? ", shopID).First(&shop).Error; err != nil {
@@ -99,3 +141,82 @@ func (r *ShopsRepositoryImpl) VerifyShopOwner(ctx context.Context, shopID string
 
 	return nil
 }
+
+// UpdateShopSuspension suspends the shop, or lifts the suspension when
+// suspendedAt is nil.
+func (r *ShopsRepositoryImpl) UpdateShopSuspension(ctx context.Context, shopID uint, suspendedAt *time.Time, reason string) (err error) {
+	return r.tx(ctx).Model(&entity.Shop{}).Where("id = ?", shopID).Updates(map[string]interface{}{
+		"suspended_at":   suspendedAt,
+		"suspend_reason": reason,
+	}).Error
+}
+
+// GetPhotoURLs returns the stored shop photos and banners.
+func (r *ShopsRepositoryImpl) GetPhotoURLs(ctx context.Context) (res []string, err error) {
+	var shops []entity.Shop
+	if err := r.tx(ctx).Select("photo_url", "banner_url").Where("photo_url <> '' OR banner_url <> ''").Find(&shops).Error; err != nil {
+		return res, err
+	}
+
+	for _, shop := range shops {
+		if shop.PhotoURL != "" {
+			res = append(res, shop.PhotoURL)
+		}
+		if shop.BannerURL != "" {
+			res = append(res, shop.BannerURL)
+		}
+	}
+
+	return res, nil
+}
+
+func (r *ShopsRepositoryImpl) GetShopStats(ctx context.Context, shopID uint) (res entity.ShopStats, err error) {
+	err = r.tx(ctx).Model(&entity.Product{}).Scopes(visibleProducts).Where("products.shop_id = ?", shopID).Count(&res.ProductCount).Error
+	if err != nil {
+		return res, err
+	}
+
+	err = r.tx(ctx).Model(&entity.TrxDetail{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("shop_id = ?", shopID).
+		Scan(&res.TotalSold).Error
+	if err != nil {
+		return res, err
+	}
+
+	err = r.tx(ctx).Model(&entity.ShopFollow{}).Where("shop_id = ?", shopID).Count(&res.FollowerCount).Error
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// CountShopFollowers returns the number of followers of each shop in
+// shopIDs, counted in a single grouped query.
+func (r *ShopsRepositoryImpl) CountShopFollowers(ctx context.Context, shopIDs []uint) (res map[uint]int64, err error) {
+	res = make(map[uint]int64, len(shopIDs))
+	if len(shopIDs) == 0 {
+		return res, nil
+	}
+
+	var rows []struct {
+		ShopID uint
+		Total  int64
+	}
+
+	err = r.tx(ctx).Model(&entity.ShopFollow{}).
+		Select("shop_id, COUNT(*) AS total").
+		Where("shop_id IN ?", shopIDs).
+		Group("shop_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		res[row.ShopID] = row.Total
+	}
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StockAlertsRepository interface {
+	Transactor
+
+	CreateStockAlert(ctx context.Context, data entity.StockAlert) (res uint, err error)
+	GetStockAlertsByShopID(ctx context.Context, params entity.FilterStockAlerts) (res []entity.StockAlert, err error)
+	GetUnsentStockAlerts(ctx context.Context, limit int, maxAttempts int, now time.Time) (res []entity.StockAlert, err error)
+	MarkStockAlertsEmailed(ctx context.Context, alertIDs []uint, emailedAt time.Time) (err error)
+	MarkStockAlertsFailed(ctx context.Context, alertIDs []uint, nextAttemptAt time.Time) (err error)
+}
+
+type StockAlertsRepositoryImpl struct {
+	transactor
+}
+
+func NewStockAlertsRepository(db *gorm.DB) StockAlertsRepository {
+	return &StockAlertsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *StockAlertsRepositoryImpl) CreateStockAlert(ctx context.Context, data entity.StockAlert) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+func (r *StockAlertsRepositoryImpl) GetStockAlertsByShopID(ctx context.Context, params entity.FilterStockAlerts) (res []entity.StockAlert, err error) {
+	db := r.tx(ctx).
+		Preload("Product").
+		Where("shop_id = ?", params.ShopID).
+		Order("id DESC")
+
+	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetUnsentStockAlerts returns the oldest alerts that were not emailed yet
+// and are due at now. Alerts that failed maxAttempts times are given up on.
+func (r *StockAlertsRepositoryImpl) GetUnsentStockAlerts(ctx context.Context, limit int, maxAttempts int, now time.Time) (res []entity.StockAlert, err error) {
+	db := r.tx(ctx).
+		Preload("Shop").
+		Preload("Product").
+		Where("emailed_at IS NULL AND attempts < ?", maxAttempts).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", now).
+		Order("id ASC")
+
+	if err := db.Limit(limit).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *StockAlertsRepositoryImpl) MarkStockAlertsEmailed(ctx context.Context, alertIDs []uint, emailedAt time.Time) (err error) {
+	if len(alertIDs) == 0 {
+		return nil
+	}
+
+	return r.tx(ctx).Model(&entity.StockAlert{}).Where("id IN ?", alertIDs).Update("emailed_at", emailedAt).Error
+}
+
+// MarkStockAlertsFailed counts a failed send of the alerts and holds them
+// back until nextAttemptAt.
+func (r *StockAlertsRepositoryImpl) MarkStockAlertsFailed(ctx context.Context, alertIDs []uint, nextAttemptAt time.Time) (err error) {
+	if len(alertIDs) == 0 {
+		return nil
+	}
+
+	return r.tx(ctx).Model(&entity.StockAlert{}).Where("id IN ?", alertIDs).Updates(map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+	}).Error
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrInsufficientStock = errors.New("stok tidak tersedia")
+
+type StockMovementsRepository interface {
+	Transactor
+
+	CreateStockMovement(ctx context.Context, data entity.StockMovement) (res uint, err error)
+	GetStockMovements(ctx context.Context, params entity.FilterStockMovements) (res []entity.StockMovement, err error)
+	CountStockMovements(ctx context.Context, productID uint) (res int64, err error)
+}
+
+type StockMovementsRepositoryImpl struct {
+	transactor
+}
+
+func NewStockMovementsRepository(db *gorm.DB) StockMovementsRepository {
+	return &StockMovementsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *StockMovementsRepositoryImpl) CreateStockMovement(ctx context.Context, data entity.StockMovement) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+func (r *StockMovementsRepositoryImpl) GetStockMovements(ctx context.Context, params entity.FilterStockMovements) (res []entity.StockMovement, err error) {
+	db := r.tx(ctx).Where("product_id = ?", params.ProductID).Order("id DESC")
+
+	if err := db.Limit(params.Limit).Offset(params.Offset).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *StockMovementsRepositoryImpl) CountStockMovements(ctx context.Context, productID uint) (res int64, err error) {
+	if err := r.tx(ctx).Model(&entity.StockMovement{}).Where("product_id = ?", productID).Count(&res).Error; err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
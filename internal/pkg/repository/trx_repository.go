@@ -36,6 +36,8 @@ func (r *TrxRepositoryImpl) CreateTrx(ctx context.Context, data entity.Trx) (res
 	return data.ID, nil
 }
 
+// GetTrxByID only finds transactions of userID, an empty userID finds the
+// transaction of any user.
 func (r *TrxRepositoryImpl) GetTrxByID(ctx context.Context, userID string, trxID string) (res entity.Trx, err error) {
 	db := r.tx(ctx).
 		Preload("TrxDetails").
@@ -44,7 +46,11 @@ func (r *TrxRepositoryImpl) GetTrxByID(ctx context.Context, userID string, trxID
 		Preload("TrxDetails.ProductLog.Shop").
 		Preload("TrxDetails.ProductLog.Category")
 
-	if err := db.Where("user_id = ?", userID).First(&res, trxID).Error; err != nil {
+	if userID != "" {
+		db = db.Where("user_id = ?", userID)
+	}
+
+	if err := db.First(&res, trxID).Error; err != nil {
 		return res, err
 	}
 
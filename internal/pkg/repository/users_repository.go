@@ -4,6 +4,7 @@ import (
 	"backend-evermos/internal/pkg/entity"
 	"context"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +16,9 @@ type UsersRepository interface {
 	CreateUser(ctx context.Context, data entity.User) (res uint, err error)
 	GetUserByID(ctx context.Context, userID string) (res entity.User, err error)
 	UpdateUserByID(ctx context.Context, userID string, data entity.User) (err error)
+	RevokeUserTokens(ctx context.Context, userID string, at time.Time) (err error)
+	UpdateEmailVerifiedAt(ctx context.Context, userID string, at *time.Time) (err error)
+	UpdateEmailVerificationSentAt(ctx context.Context, userID string, at time.Time) (err error)
 
 	VerifyEmail(ctx context.Context, email string) (err error)
 	VerifyPhoneNumber(ctx context.Context, phoneNumber string) (err error)
@@ -69,6 +73,23 @@ func (r *UsersRepositoryImpl) UpdateUserByID(ctx context.Context, userID string,
 	return nil
 }
 
+// RevokeUserTokens stores at to the millisecond like the iat_ms claim of
+// access tokens, MySQL would otherwise round it and could reject a token
+// issued right after the revocation.
+func (r *UsersRepositoryImpl) RevokeUserTokens(ctx context.Context, userID string, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("tokens_revoked_at", at.Truncate(time.Millisecond)).Error
+}
+
+// UpdateEmailVerifiedAt sets when the email of the user was verified, nil
+// marks it unverified again.
+func (r *UsersRepositoryImpl) UpdateEmailVerifiedAt(ctx context.Context, userID string, at *time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("email_verified_at", at).Error
+}
+
+func (r *UsersRepositoryImpl) UpdateEmailVerificationSentAt(ctx context.Context, userID string, at time.Time) (err error) {
+	return r.tx(ctx).Model(&entity.User{}).Where("id = ?", userID).Update("email_verification_sent_at", at).Error
+}
+
 func (r *UsersRepositoryImpl) VerifyEmail(ctx context.Context, email string) (err error) {
 	var count int64
 	if err := r.tx(ctx).Model(&entity.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
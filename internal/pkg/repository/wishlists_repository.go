@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type WishlistsRepository interface {
+	Transactor
+
+	CreateWishlist(ctx context.Context, data entity.Wishlist) (res uint, err error)
+	GetWishlistsByUserID(ctx context.Context, userID string, params entity.FilterWishlists) (res []entity.Wishlist, err error)
+	GetWishlistedProductIDs(ctx context.Context, userID string, productIDs []uint) (res []uint, err error)
+	DeleteWishlist(ctx context.Context, userID string, productID string) (err error)
+	DeleteWishlistsByProductID(ctx context.Context, productID string) (err error)
+
+	VerifyWishlistAvailability(ctx context.Context, userID string, productID string) (err error)
+}
+
+type WishlistsRepositoryImpl struct {
+	transactor
+}
+
+func NewWishlistsRepository(db *gorm.DB) WishlistsRepository {
+	return &WishlistsRepositoryImpl{
+		transactor: transactor{
+			db: db,
+		},
+	}
+}
+
+func (r *WishlistsRepositoryImpl) CreateWishlist(ctx context.Context, data entity.Wishlist) (res uint, err error) {
+	result := r.tx(ctx).Create(&data)
+	if result.Error != nil {
+		return res, result.Error
+	}
+
+	return data.ID, nil
+}
+
+// GetWishlistsByUserID leaves out products hidden by moderation, they come
+// back once the product or its shop is restored.
+func (r *WishlistsRepositoryImpl) GetWishlistsByUserID(ctx context.Context, userID string, params entity.FilterWishlists) (res []entity.Wishlist, err error) {
+	db := r.tx(ctx).
+		Joins("JOIN products ON products.id = wishlists.product_id AND products.deleted_at IS NULL").
+		Scopes(visibleProducts).
+		Preload("Product").
+		Preload("Product.Shop").
+		Preload("Product.Category").
+		Preload("Product.Images", orderedImages)
+
+	if err := db.Where("wishlists.user_id = ?", userID).
+		Order("wishlists.created_at DESC").
+		Limit(params.Limit).Offset(params.Offset).
+		Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *WishlistsRepositoryImpl) GetWishlistedProductIDs(ctx context.Context, userID string, productIDs []uint) (res []uint, err error) {
+	if len(productIDs) == 0 {
+		return res, nil
+	}
+
+	if err := r.tx(ctx).Model(&entity.Wishlist{}).
+		Where("user_id = ? AND product_id IN ?", userID, productIDs).
+		Pluck("product_id", &res).Error; err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *WishlistsRepositoryImpl) DeleteWishlist(ctx context.Context, userID string, productID string) (err error) {
+	result := r.tx(ctx).Unscoped().Where("user_id = ? AND product_id = ?", userID, productID).Delete(&entity.Wishlist{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *WishlistsRepositoryImpl) DeleteWishlistsByProductID(ctx context.Context, productID string) (err error) {
+	if err := r.tx(ctx).Unscoped().Where("product_id = ?", productID).Delete(&entity.Wishlist{}).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *WishlistsRepositoryImpl) VerifyWishlistAvailability(ctx context.Context, userID string, productID string) (err error) {
+	var wishlist entity.Wishlist
+	if err := r.tx(ctx).Where("user_id = ? AND product_id = ?", userID, productID).First(&wishlist).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package usecase
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/mailer"
+	"backend-evermos/internal/infrastructure/otp"
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	userModel "backend-evermos/internal/pkg/model"
@@ -11,37 +13,168 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
+const (
+	// refreshTokenSize is the number of random bytes in a refresh token.
+	refreshTokenSize = 32
+	otpDigits        = 6
+)
+
+// Actions EmailVerificationConf can restrict to users with a verified email.
+const (
+	VerifiedEmailForCheckout = "checkout"
+	VerifiedEmailForSelling  = "selling"
+)
+
+var (
+	errRefreshTokenInvalid = errors.New("refresh token tidak valid")
+	errOTPInvalid          = errors.New("kode OTP tidak valid")
+	errOTPExpired          = errors.New("kode OTP sudah tidak berlaku, silakan minta kode baru")
+	errOTPDailyLimit       = errors.New("terlalu banyak percobaan kode OTP, silakan coba lagi besok")
+	errOTPThrottled        = errors.New("kode OTP baru saja dikirim")
+)
+
 type AuthUseCase interface {
-	Login(ctx context.Context, params userModel.Login) (res userModel.LoginRes, err *helper.ErrorStruct)
+	Login(ctx context.Context, params userModel.Login, clientIP string) (res userModel.LoginRes, err *helper.ErrorStruct)
 	CreateUser(ctx context.Context, data userModel.UserReqCreate) (res uint, err *helper.ErrorStruct)
+	RefreshToken(ctx context.Context, data userModel.RefreshTokenReq) (res userModel.TokenResp, err *helper.ErrorStruct)
+	Logout(ctx context.Context, userID string, data userModel.LogoutReq) (res string, err *helper.ErrorStruct)
+	VerifyAccessToken(ctx context.Context, userID string, issuedAt time.Time) (res userModel.AccessResp, err *helper.ErrorStruct)
+	ForgotPassword(ctx context.Context, data userModel.ForgotPasswordReq) (res string, err *helper.ErrorStruct)
+	ResetPassword(ctx context.Context, data userModel.ResetPasswordReq) (res string, err *helper.ErrorStruct)
+	VerifyEmail(ctx context.Context, token string) (res string, err *helper.ErrorStruct)
+	ResendEmailVerification(ctx context.Context, userID string) (res string, err *helper.ErrorStruct)
+	RequireVerifiedEmail(ctx context.Context, userID string, action string) (err *helper.ErrorStruct)
+	UnlockLogin(ctx context.Context, adminID string, data userModel.LoginUnlockReq) (res string, err *helper.ErrorStruct)
+}
+
+// AuthTokenTTL is how long access and refresh tokens stay valid.
+type AuthTokenTTL struct {
+	Access  time.Duration
+	Refresh time.Duration
+}
+
+// PasswordResetConf limits the one time passwords sent to reset a password.
+type PasswordResetConf struct {
+	OTPTTL         time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+
+	// DailyCodes and DailyAttempts cap the codes sent to a number and the
+	// wrong codes entered for it within 24 hours.
+	DailyCodes    int
+	DailyAttempts int
+}
+
+// EmailVerificationConf configures the links mailed to verify an email and
+// which actions need a verified email.
+type EmailVerificationConf struct {
+	// LinkBaseURL is the public base URL the verification links point to.
+	LinkBaseURL    string
+	LinkTTL        time.Duration
+	ResendInterval time.Duration
+
+	RequireForCheckout bool
+	RequireForSelling  bool
+}
+
+// LoginGuardConf locks logins of a phone number or an IP address after
+// repeated failures within Window. The lockout starts at Lockout and doubles
+// with every further failure, up to MaxLockout. A zero limit disables it.
+type LoginGuardConf struct {
+	MaxAccountFailures int
+	MaxIPFailures      int
+	Window             time.Duration
+	Lockout            time.Duration
+	MaxLockout         time.Duration
 }
 
 type AuthUseCaseImpl struct {
-	usersRepository    repository.UsersRepository
-	shopsRepository    repository.ShopsRepository
-	provcityRepository repository.ProvcityRepository
+	usersRepository         repository.UsersRepository
+	shopsRepository         repository.ShopsRepository
+	provcityRepository      repository.ProvcityRepository
+	refreshTokensRepository repository.RefreshTokensRepository
+	tokenTTL                AuthTokenTTL
+
+	passwordResetsRepository repository.PasswordResetsRepository
+	otpSender                otp.OTPSender
+	passwordReset            PasswordResetConf
+
+	mailer            mailer.Mailer
+	emailVerification EmailVerificationConf
+
+	loginAttemptStore repository.LoginAttemptStore
+	loginGuard        LoginGuardConf
+
+	rolesRepository repository.RolesRepository
 }
 
 func NewAuthUseCase(
 	usersRepository repository.UsersRepository,
 	shopsRepository repository.ShopsRepository,
 	provcityRepository repository.ProvcityRepository,
+	refreshTokensRepository repository.RefreshTokensRepository,
+	tokenTTL AuthTokenTTL,
+	passwordResetsRepository repository.PasswordResetsRepository,
+	otpSender otp.OTPSender,
+	passwordReset PasswordResetConf,
+	mailer mailer.Mailer,
+	emailVerification EmailVerificationConf,
+	loginAttemptStore repository.LoginAttemptStore,
+	loginGuard LoginGuardConf,
+	rolesRepository repository.RolesRepository,
 ) AuthUseCase {
 	return &AuthUseCaseImpl{
-		usersRepository:    usersRepository,
-		shopsRepository:    shopsRepository,
-		provcityRepository: provcityRepository,
+		usersRepository:         usersRepository,
+		shopsRepository:         shopsRepository,
+		provcityRepository:      provcityRepository,
+		refreshTokensRepository: refreshTokensRepository,
+		tokenTTL:                tokenTTL,
+
+		passwordResetsRepository: passwordResetsRepository,
+		otpSender:                otpSender,
+		passwordReset:            passwordReset,
+
+		mailer:            mailer,
+		emailVerification: emailVerification,
+
+		loginAttemptStore: loginAttemptStore,
+		loginGuard:        loginGuard,
+
+		rolesRepository: rolesRepository,
 	}
 }
 
-func (alc *AuthUseCaseImpl) Login(ctx context.Context, params userModel.Login) (res userModel.LoginRes, err *helper.ErrorStruct) {
+func (alc *AuthUseCaseImpl) Login(ctx context.Context, params userModel.Login, clientIP string) (res userModel.LoginRes, err *helper.ErrorStruct) {
+	attempts, lockedFor, errGuard := alc.reserveLoginAttempt(ctx, params.PhoneNumber, clientIP)
+	if errGuard != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at reserveLoginAttempt: %s", errGuard.Error()), errGuard)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal login"),
+		}
+	}
+
+	if lockedFor > 0 {
+		logSecurityEvent("login_blocked", params.PhoneNumber, clientIP)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusTooManyRequests,
+			Err:  fmt.Errorf("terlalu banyak percobaan login, coba lagi dalam %s", lockedFor.Round(time.Second)),
+		}
+	}
+
 	resRepo, errRepo := alc.usersRepository.GetUserByPhoneNumber(ctx, params.PhoneNumber)
 	if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		// Unknown numbers take as long as wrong passwords, so timing
+		// does not tell which numbers are registered.
+		utils.EqualizePasswordCheck(params.Password)
+		alc.recordLoginFailure(ctx, params.PhoneNumber, clientIP, attempts)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusUnauthorized,
 			Err:  errors.New("no telp atau kata sandi salah"),
@@ -49,6 +182,7 @@ func (alc *AuthUseCaseImpl) Login(ctx context.Context, params userModel.Login) (
 	}
 
 	if errRepo != nil {
+		alc.releaseLoginAttempt(ctx, params.PhoneNumber, clientIP)
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetAllUsers : %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
@@ -58,26 +192,41 @@ func (alc *AuthUseCaseImpl) Login(ctx context.Context, params userModel.Login) (
 
 	isValid := utils.CheckPasswordHash(params.Password, resRepo.Password)
 	if !isValid {
+		alc.recordLoginFailure(ctx, params.PhoneNumber, clientIP, attempts)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusUnauthorized,
 			Err:  errors.New("no telp atau kata sandi salah"),
 		}
 	}
 
-	tokenInit := utils.NewToken(utils.DataClaims{
-		ID:      fmt.Sprint(resRepo.ID),
-		Email:   resRepo.Email,
-		IsAdmin: resRepo.IsAdmin,
-	})
+	alc.releaseLoginAttempt(ctx, params.PhoneNumber, clientIP)
+
+	familyID, errToken := utils.GenerateOpaqueToken(16)
+	if errToken != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errToken,
+		}
+	}
 
-	token, errToken := tokenInit.Create()
+	tokens, errToken := alc.issueTokens(ctx, resRepo, familyID)
 	if errToken != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at issueTokens: %s", errToken.Error()), errToken)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusUnauthorized,
 			Err:  errToken,
 		}
 	}
 
+	roles, errRepo := alc.rolesRepository.GetUserRoleNames(ctx, fmt.Sprint(resRepo.ID))
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserRoleNames: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
 	provinceID := resRepo.ProvinceID
 	provRepo, errRepo := alc.provcityRepository.GetProvinceByID(provinceID)
 	if errRepo != nil {
@@ -132,7 +281,10 @@ func (alc *AuthUseCaseImpl) Login(ctx context.Context, params userModel.Login) (
 		Email:       resRepo.Email,
 		ProvinceID:  province,
 		CityID:      city,
-		Token:       token,
+
+		EmailVerified: resRepo.EmailVerifiedAt != nil,
+		Roles:         roles,
+		TokenResp:     tokens,
 	}
 
 	return res, nil
@@ -188,9 +340,28 @@ func (alc *AuthUseCaseImpl) CreateUser(ctx context.Context, params userModel.Use
 	}
 
 	shopName := utils.GenerateShopName(params.Email)
+	shopSlug := utils.ShopHandleFromName(shopName)
+	takenSlugs, errRepo := alc.shopsRepository.GetTakenSlugs(ctx, shopSlug)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetTakenSlugs: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+	shopSlug = utils.UniqueSlug(shopSlug, takenSlugs)
 
+	if errHandle := utils.ValidateShopHandle(shopSlug); errHandle != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ValidateShopHandle: %s", errHandle.Error()), errHandle)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal membuat handle toko"),
+		}
+	}
+
+	var user entity.User
 	errTransaction := alc.usersRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
-		userID, err := alc.usersRepository.CreateUser(txCtx, entity.User{
+		user = entity.User{
 			Email:       params.Email,
 			Name:        params.Name,
 			Password:    hashPass,
@@ -200,23 +371,43 @@ func (alc *AuthUseCaseImpl) CreateUser(ctx context.Context, params userModel.Use
 			JobTitle:    params.JobTitle,
 			ProvinceID:  params.ProvinceID,
 			CityID:      params.CityID,
-			IsAdmin:     false,
-		})
+		}
+		user.ID, err = alc.usersRepository.CreateUser(txCtx, user)
+		if err != nil {
+			return err
+		}
+
+		roles, err := alc.rolesRepository.GetRolesByNames(txCtx, entity.DefaultUserRoles)
 		if err != nil {
 			return err
 		}
 
+		if err = alc.rolesRepository.AddUserRoles(txCtx, user.ID, roles); err != nil {
+			return err
+		}
+
 		_, err = alc.shopsRepository.CreateShop(txCtx, entity.Shop{
-			UserID:   userID,
+			UserID:   user.ID,
 			ShopName: shopName,
+			Slug:     shopSlug,
 			PhotoURL: "",
 		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errShopHandleTaken
+		}
 		if err != nil {
 			return err
 		}
 
 		return nil
 	})
+	// Another registration took the generated handle in the meantime.
+	if errors.Is(errTransaction, errShopHandleTaken) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusConflict,
+			Err:  errors.New("handle toko sudah digunakan, silakan coba lagi"),
+		}
+	}
 	if errTransaction != nil {
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at Transactions: %s", errTransaction.Error()), errTransaction)
 		return res, &helper.ErrorStruct{
@@ -225,5 +416,711 @@ func (alc *AuthUseCaseImpl) CreateUser(ctx context.Context, params userModel.Use
 		}
 	}
 
+	// The account exists either way, a failed email can be resent.
+	if errSend := sendEmailVerification(ctx, alc.usersRepository, alc.mailer, alc.emailVerification, user); errSend != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at sendEmailVerification: %s", errSend.Error()), errSend)
+	}
+
+	return res, nil
+}
+
+// RefreshToken trades a refresh token for a new access token and a new
+// refresh token of the same family. A refresh token that was already used
+// revokes its whole family, whoever presents it.
+func (alc *AuthUseCaseImpl) RefreshToken(ctx context.Context, data userModel.RefreshTokenReq) (res userModel.TokenResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	var reused bool
+	errTransaction := alc.refreshTokensRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		now := time.Now()
+
+		token, err := alc.refreshTokensRepository.GetRefreshTokenByHash(txCtx, utils.HashOpaqueToken(data.RefreshToken))
+		if err != nil {
+			return err
+		}
+
+		if token.UsedAt != nil || token.RevokedAt != nil {
+			// The revocation has to be committed, so this is not
+			// returned as an error.
+			reused = true
+			return alc.refreshTokensRepository.RevokeRefreshTokenFamily(txCtx, token.FamilyID, now)
+		}
+
+		if now.After(token.ExpiresAt) {
+			return errRefreshTokenInvalid
+		}
+
+		user, err := alc.usersRepository.GetUserByID(txCtx, fmt.Sprint(token.UserID))
+		if err != nil {
+			return err
+		}
+
+		if user.TokensRevokedAt != nil && token.CreatedAt.Before(*user.TokensRevokedAt) {
+			return errRefreshTokenInvalid
+		}
+
+		if err = alc.refreshTokensRepository.MarkRefreshTokenUsed(txCtx, token.ID, now); err != nil {
+			return err
+		}
+
+		res, err = alc.issueTokens(txCtx, user, token.FamilyID)
+		return err
+	})
+	if errors.Is(errTransaction, gorm.ErrRecordNotFound) || errors.Is(errTransaction, errRefreshTokenInvalid) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusUnauthorized,
+			Err:  errRefreshTokenInvalid,
+		}
+	}
+
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at Transactions: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal memperbarui token"),
+		}
+	}
+
+	if reused {
+		helper.Logger(helper.LoggerLevelWarn, "Reused refresh token, revoked its family", nil)
+		return userModel.TokenResp{}, &helper.ErrorStruct{
+			Code: fiber.StatusUnauthorized,
+			Err:  errRefreshTokenInvalid,
+		}
+	}
+
 	return res, nil
 }
+
+// Logout revokes the refresh token family of the current session. Logging
+// out of all sessions also rejects every access token issued so far.
+func (alc *AuthUseCaseImpl) Logout(ctx context.Context, userID string, data userModel.LogoutReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	if data.All {
+		if errRepo := alc.usersRepository.RevokeUserTokens(ctx, userID, time.Now()); errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at RevokeUserTokens: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusInternalServerError,
+				Err:  errors.New("gagal logout"),
+			}
+		}
+
+		return "logged out", nil
+	}
+
+	token, errRepo := alc.refreshTokensRepository.GetRefreshTokenByHash(ctx, utils.HashOpaqueToken(data.RefreshToken))
+	if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetRefreshTokenByHash: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal logout"),
+		}
+	}
+
+	if errRepo != nil || fmt.Sprint(token.UserID) != userID {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusUnauthorized,
+			Err:  errRefreshTokenInvalid,
+		}
+	}
+
+	if errRepo := alc.refreshTokensRepository.RevokeRefreshTokenFamily(ctx, token.FamilyID, time.Now()); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at RevokeRefreshTokenFamily: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal logout"),
+		}
+	}
+
+	return "logged out", nil
+}
+
+// VerifyAccessToken rejects access tokens issued before the user revoked
+// their tokens, and returns the current roles and permissions of the user so
+// role changes apply without waiting for the token to expire.
+func (alc *AuthUseCaseImpl) VerifyAccessToken(ctx context.Context, userID string, issuedAt time.Time) (res userModel.AccessResp, err *helper.ErrorStruct) {
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusUnauthorized,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	if user.TokensRevokedAt != nil && issuedAt.Before(*user.TokensRevokedAt) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusUnauthorized,
+			Err:  errors.New("token telah dicabut"),
+		}
+	}
+
+	access, errRepo := alc.rolesRepository.GetUserAccess(ctx, userID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserAccess: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	res = userModel.AccessResp{
+		Roles:       access.Roles,
+		Permissions: access.Permissions,
+	}
+
+	return res, nil
+}
+
+// ForgotPassword sends a one time password to the phone of the user, which
+// ResetPassword accepts in place of the old password. Unknown numbers, and
+// codes that are throttled or fail to send, get the same answer, so the
+// endpoint cannot tell which numbers are registered.
+func (alc *AuthUseCaseImpl) ForgotPassword(ctx context.Context, data userModel.ForgotPasswordReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	res = "kode OTP telah dikirim jika nomor terdaftar"
+
+	user, errRepo := alc.usersRepository.GetUserByPhoneNumber(ctx, data.PhoneNumber)
+	if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		return res, nil
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByPhoneNumber: %s", errRepo.Error()), errRepo)
+		return "", &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengirim kode OTP"),
+		}
+	}
+
+	errSend := alc.sendPasswordResetOTP(ctx, user)
+	if errors.Is(errSend, errOTPThrottled) || errors.Is(errSend, errOTPDailyLimit) {
+		helper.Logger(helper.LoggerLevelWarn, fmt.Sprintf("Password reset of user %d not sent: %s", user.ID, errSend.Error()), errSend)
+	} else if errSend != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at sendPasswordResetOTP: %s", errSend.Error()), errSend)
+	}
+
+	return res, nil
+}
+
+// sendPasswordResetOTP retires the unused codes of user and sends a new
+// one, unless one was sent within ResendInterval or the daily caps are
+// reached.
+func (alc *AuthUseCaseImpl) sendPasswordResetOTP(ctx context.Context, user entity.User) error {
+	now := time.Now()
+
+	latest, err := alc.passwordResetsRepository.GetLatestPasswordReset(ctx, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err == nil && now.Sub(latest.CreatedAt) < alc.passwordReset.ResendInterval {
+		return errOTPThrottled
+	}
+
+	usage, err := alc.passwordResetsRepository.GetPasswordResetUsage(ctx, user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if usage.Codes >= int64(alc.passwordReset.DailyCodes) || usage.Attempts >= int64(alc.passwordReset.DailyAttempts) {
+		return errOTPDailyLimit
+	}
+
+	code, err := utils.GenerateOTP(otpDigits)
+	if err != nil {
+		return err
+	}
+
+	codeHash, err := utils.HashOTP(code)
+	if err != nil {
+		return err
+	}
+
+	err = alc.passwordResetsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		if err = alc.passwordResetsRepository.MarkPasswordResetsUsed(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		_, err = alc.passwordResetsRepository.CreatePasswordReset(txCtx, entity.PasswordReset{
+			UserID:    user.ID,
+			CodeHash:  codeHash,
+			ExpiresAt: now.Add(alc.passwordReset.OTPTTL),
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return alc.otpSender.Send(ctx, otp.Message{
+		To:   user.PhoneNumber,
+		Body: fmt.Sprintf("Kode reset kata sandi Anda: %s. Berlaku %d menit. Jangan berikan kode ini kepada siapa pun.", code, int(alc.passwordReset.OTPTTL.Minutes())),
+	})
+}
+
+// ResetPassword replaces the password of the user when the one time password
+// matches. Every wrong guess counts against the code and the daily cap of
+// the number, and a successful reset logs the user out of all sessions.
+func (alc *AuthUseCaseImpl) ResetPassword(ctx context.Context, data userModel.ResetPasswordReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	user, errRepo := alc.usersRepository.GetUserByPhoneNumber(ctx, data.PhoneNumber)
+	if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errOTPInvalid,
+		}
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByPhoneNumber: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengatur ulang kata sandi"),
+		}
+	}
+
+	var wrongCode bool
+	errTransaction := alc.passwordResetsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		now := time.Now()
+
+		reset, err := alc.passwordResetsRepository.GetLatestPasswordReset(txCtx, user.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errOTPInvalid
+		}
+		if err != nil {
+			return err
+		}
+
+		if now.After(reset.ExpiresAt) || reset.Attempts >= alc.passwordReset.MaxAttempts {
+			return errOTPExpired
+		}
+
+		usage, err := alc.passwordResetsRepository.GetPasswordResetUsage(txCtx, user.ID, now.Add(-24*time.Hour))
+		if err != nil {
+			return err
+		}
+
+		if usage.Attempts >= int64(alc.passwordReset.DailyAttempts) {
+			return errOTPDailyLimit
+		}
+
+		if !utils.CheckOTPHash(data.OTP, reset.CodeHash) {
+			// The attempt has to be committed, so this is not
+			// returned as an error.
+			wrongCode = true
+			return alc.passwordResetsRepository.IncrementPasswordResetAttempts(txCtx, reset.ID)
+		}
+
+		hashPass, err := utils.HashPassword(data.NewPassword)
+		if err != nil {
+			return err
+		}
+
+		userID := fmt.Sprint(user.ID)
+		if err = alc.usersRepository.UpdateUserByID(txCtx, userID, entity.User{Password: hashPass}); err != nil {
+			return err
+		}
+
+		if err = alc.passwordResetsRepository.MarkPasswordResetsUsed(txCtx, user.ID, now); err != nil {
+			return err
+		}
+
+		return alc.usersRepository.RevokeUserTokens(txCtx, userID, now)
+	})
+	if errors.Is(errTransaction, errOTPInvalid) || errors.Is(errTransaction, errOTPExpired) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errTransaction,
+		}
+	}
+
+	if errors.Is(errTransaction, errOTPDailyLimit) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusTooManyRequests,
+			Err:  errOTPDailyLimit,
+		}
+	}
+
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at Transactions: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengatur ulang kata sandi"),
+		}
+	}
+
+	if wrongCode {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errOTPInvalid,
+		}
+	}
+
+	if errGuard := alc.loginAttemptStore.ResetLoginAttempts(ctx, accountLoginKey(user.PhoneNumber)); errGuard != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ResetLoginAttempts: %s", errGuard.Error()), errGuard)
+	}
+
+	return "kata sandi berhasil diubah", nil
+}
+
+// VerifyEmail marks the email of a verification link as verified, as long
+// as it is still the email of the user.
+func (alc *AuthUseCaseImpl) VerifyEmail(ctx context.Context, token string) (res string, err *helper.ErrorStruct) {
+	userID, email, errToken := utils.VerifyEmailVerification(token)
+	if errToken != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errToken,
+		}
+	}
+
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, fmt.Sprint(userID))
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  utils.ErrEmailVerificationInvalid,
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal memverifikasi email"),
+		}
+	}
+
+	if user.Email != email {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  utils.ErrEmailVerificationInvalid,
+		}
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return "email sudah diverifikasi", nil
+	}
+
+	now := time.Now()
+	if errRepo := alc.usersRepository.UpdateEmailVerifiedAt(ctx, fmt.Sprint(user.ID), &now); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateEmailVerifiedAt: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal memverifikasi email"),
+		}
+	}
+
+	return "email berhasil diverifikasi", nil
+}
+
+func (alc *AuthUseCaseImpl) ResendEmailVerification(ctx context.Context, userID string) (res string, err *helper.ErrorStruct) {
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengirim email verifikasi"),
+		}
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("email sudah diverifikasi"),
+		}
+	}
+
+	if user.EmailVerificationSentAt != nil && time.Since(*user.EmailVerificationSentAt) < alc.emailVerification.ResendInterval {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusTooManyRequests,
+			Err:  errors.New("email verifikasi baru saja dikirim, silakan tunggu sebelum meminta lagi"),
+		}
+	}
+
+	if errSend := sendEmailVerification(ctx, alc.usersRepository, alc.mailer, alc.emailVerification, user); errSend != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at sendEmailVerification: %s", errSend.Error()), errSend)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadGateway,
+			Err:  errors.New("gagal mengirim email verifikasi"),
+		}
+	}
+
+	return "email verifikasi telah dikirim", nil
+}
+
+// RequireVerifiedEmail rejects users without a verified email when the
+// configured policy restricts action to verified users.
+func (alc *AuthUseCaseImpl) RequireVerifiedEmail(ctx context.Context, userID string, action string) (err *helper.ErrorStruct) {
+	required := (action == VerifiedEmailForCheckout && alc.emailVerification.RequireForCheckout) ||
+		(action == VerifiedEmailForSelling && alc.emailVerification.RequireForSelling)
+	if !required {
+		return nil
+	}
+
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return &helper.ErrorStruct{
+				Code: fiber.StatusUnauthorized,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusForbidden,
+			Err:  errors.New("email belum diverifikasi"),
+		}
+	}
+
+	return nil
+}
+
+// UnlockLogin lets an admin lift the lockout of a phone number, an IP
+// address or both before it expires.
+func (alc *AuthUseCaseImpl) UnlockLogin(ctx context.Context, adminID string, data userModel.LoginUnlockReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	var keys []string
+	if data.PhoneNumber != "" {
+		keys = append(keys, accountLoginKey(data.PhoneNumber))
+	}
+	if data.IP != "" {
+		keys = append(keys, ipLoginKey(data.IP))
+	}
+
+	for _, key := range keys {
+		if errRepo := alc.loginAttemptStore.ResetLoginAttempts(ctx, key); errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ResetLoginAttempts: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusInternalServerError,
+				Err:  errors.New("gagal membuka kunci login"),
+			}
+		}
+	}
+
+	logSecurityEvent(fmt.Sprintf("login_unlocked by admin %s", adminID), data.PhoneNumber, data.IP)
+	return "kunci login berhasil dibuka", nil
+}
+
+// loginLockedFor returns how long logins of phoneNumber or from clientIP are
+// still locked, zero when neither is.
+func (alc *AuthUseCaseImpl) loginLockedFor(ctx context.Context, phoneNumber string, clientIP string) (lockedFor time.Duration, err error) {
+	now := time.Now()
+	for _, key := range []string{accountLoginKey(phoneNumber), ipLoginKey(clientIP)} {
+		attempt, err := alc.loginAttemptStore.GetLoginAttempt(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+
+		if attempt.LockedUntil != nil && attempt.LockedUntil.Sub(now) > lockedFor {
+			lockedFor = attempt.LockedUntil.Sub(now)
+		}
+	}
+
+	return lockedFor, nil
+}
+
+// loginLimits maps the counters a login of phoneNumber from clientIP counts
+// against to their failure limits.
+func (alc *AuthUseCaseImpl) loginLimits(phoneNumber string, clientIP string) map[string]int {
+	return map[string]int{
+		accountLoginKey(phoneNumber): alc.loginGuard.MaxAccountFailures,
+		ipLoginKey(clientIP):         alc.loginGuard.MaxIPFailures,
+	}
+}
+
+// reserveLoginAttempt counts a login as failed before the password is
+// checked, so parallel guesses cannot all pass the limit before any of them
+// is recorded. A login past the limit of a counter is refused and locks it.
+// It returns the reserved counters and how long the login is refused, zero
+// when it may go ahead.
+func (alc *AuthUseCaseImpl) reserveLoginAttempt(ctx context.Context, phoneNumber string, clientIP string) (attempts map[string]entity.LoginAttempt, lockedFor time.Duration, err error) {
+	lockedFor, err = alc.loginLockedFor(ctx, phoneNumber, clientIP)
+	if err != nil || lockedFor > 0 {
+		return nil, lockedFor, err
+	}
+
+	now := time.Now()
+	attempts = map[string]entity.LoginAttempt{}
+	for key, limit := range alc.loginLimits(phoneNumber, clientIP) {
+		attempt, err := alc.loginAttemptStore.RecordLoginFailure(ctx, key, now, alc.loginGuard.Window)
+		if err != nil {
+			return nil, 0, err
+		}
+		attempts[key] = attempt
+
+		if limit > 0 && attempt.Failures > limit {
+			if lockout := alc.lockLogin(ctx, key, limit, attempt.Failures, phoneNumber, clientIP); lockout > lockedFor {
+				lockedFor = lockout
+			}
+		}
+	}
+
+	return attempts, lockedFor, nil
+}
+
+// recordLoginFailure locks the counters of a failed login that reached
+// their limit, the failure itself was counted by reserveLoginAttempt. The
+// login fails anyway, so errors are only logged.
+func (alc *AuthUseCaseImpl) recordLoginFailure(ctx context.Context, phoneNumber string, clientIP string, attempts map[string]entity.LoginAttempt) {
+	logSecurityEvent("login_failed", phoneNumber, clientIP)
+
+	for key, limit := range alc.loginLimits(phoneNumber, clientIP) {
+		if attempt := attempts[key]; limit > 0 && attempt.Failures >= limit {
+			alc.lockLogin(ctx, key, limit, attempt.Failures, phoneNumber, clientIP)
+		}
+	}
+}
+
+// releaseLoginAttempt takes back the attempt reserved for a login that did
+// not fail. The account counter starts over, the IP counter only gives the
+// attempt back, one valid account must not clear the failures against
+// other accounts.
+func (alc *AuthUseCaseImpl) releaseLoginAttempt(ctx context.Context, phoneNumber string, clientIP string) {
+	if err := alc.loginAttemptStore.ResetLoginAttempts(ctx, accountLoginKey(phoneNumber)); err != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ResetLoginAttempts: %s", err.Error()), err)
+	}
+
+	if err := alc.loginAttemptStore.ReleaseLoginAttempt(ctx, ipLoginKey(clientIP)); err != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ReleaseLoginAttempt: %s", err.Error()), err)
+	}
+}
+
+// lockLogin locks key for Lockout, doubled for every failure past limit up
+// to MaxLockout, and returns the lockout.
+func (alc *AuthUseCaseImpl) lockLogin(ctx context.Context, key string, limit int, failures int, phoneNumber string, clientIP string) time.Duration {
+	lockout := alc.loginGuard.Lockout
+	for i := limit; i < failures && lockout < alc.loginGuard.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > alc.loginGuard.MaxLockout {
+		lockout = alc.loginGuard.MaxLockout
+	}
+
+	if err := alc.loginAttemptStore.LockLogin(ctx, key, time.Now().Add(lockout)); err != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at LockLogin: %s", err.Error()), err)
+		return lockout
+	}
+
+	logSecurityEvent(fmt.Sprintf("login_locked %s for %s", key, lockout), phoneNumber, clientIP)
+	return lockout
+}
+
+func accountLoginKey(phoneNumber string) string {
+	return "phone:" + phoneNumber
+}
+
+func ipLoginKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// logSecurityEvent logs login events at the warn level, so they stand out
+// from regular request logs.
+func logSecurityEvent(event string, phoneNumber string, clientIP string) {
+	helper.Logger(helper.LoggerLevelWarn, fmt.Sprintf("Security event %s: no_telp=%s ip=%s", event, phoneNumber, clientIP), nil)
+}
+
+// sendEmailVerification mails a verification link for the current email of
+// user and records when it was sent, for throttling resends.
+func sendEmailVerification(ctx context.Context, usersRepository repository.UsersRepository, mail mailer.Mailer, conf EmailVerificationConf, user entity.User) error {
+	token := utils.SignEmailVerification(user.ID, user.Email, time.Now().Add(conf.LinkTTL))
+	link := fmt.Sprintf("%s/api/v1/auth/verify-email?token=%s", conf.LinkBaseURL, url.QueryEscape(token))
+
+	err := mail.Send(ctx, mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Verifikasi email Anda",
+		Body: fmt.Sprintf("Halo %s,\n\nSilakan buka link berikut untuk memverifikasi email Anda:\n%s\n\nLink ini berlaku selama %d jam.",
+			user.Name, link, int(conf.LinkTTL.Hours())),
+	})
+	if err != nil {
+		return err
+	}
+
+	return usersRepository.UpdateEmailVerificationSentAt(ctx, fmt.Sprint(user.ID), time.Now())
+}
+
+// issueTokens creates an access token for user and stores a new refresh
+// token in familyID.
+func (alc *AuthUseCaseImpl) issueTokens(ctx context.Context, user entity.User, familyID string) (res userModel.TokenResp, err error) {
+	claims := utils.NewToken(utils.DataClaims{
+		ID:    fmt.Sprint(user.ID),
+		Email: user.Email,
+	}, alc.tokenTTL.Access)
+
+	token, err := claims.Create()
+	if err != nil {
+		return res, err
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken(refreshTokenSize)
+	if err != nil {
+		return res, err
+	}
+
+	_, err = alc.refreshTokensRepository.CreateRefreshToken(ctx, entity.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashOpaqueToken(refreshToken),
+		ExpiresAt: time.Now().Add(alc.tokenTTL.Refresh),
+	})
+	if err != nil {
+		return res, err
+	}
+
+	return userModel.TokenResp{
+		Token:          token,
+		TokenExpiresAt: time.Unix(claims.ExpiresAt, 0),
+		RefreshToken:   refreshToken,
+	}, nil
+}
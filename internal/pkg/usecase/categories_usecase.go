@@ -5,6 +5,7 @@ import (
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/repository"
+	"backend-evermos/internal/utils"
 	"context"
 	"errors"
 	"fmt"
@@ -14,29 +15,37 @@ import (
 	"gorm.io/gorm"
 )
 
+var errCategoryHasProducts = errors.New("kategori masih memiliki produk, pilih kategori tujuan dengan reassign_to")
+
 type CategoriesUseCase interface {
 	CreateCategory(ctx context.Context, data model.CategoryReqCreate) (res uint, err *helper.ErrorStruct)
 	GetCategories(ctx context.Context) (res []model.CategoryResp, err *helper.ErrorStruct)
+	GetCategoryTree(ctx context.Context) (res []model.CategoryTreeResp, err *helper.ErrorStruct)
 	GetCategoryByID(ctx context.Context, categoryID string) (res model.CategoryResp, err *helper.ErrorStruct)
 	UpdateCategoryByID(ctx context.Context, categoryID string, data model.CategoryReqUpdate) (res string, err *helper.ErrorStruct)
-	DeleteCategoryByID(ctx context.Context, categoryID string) (res string, err *helper.ErrorStruct)
+	MoveCategory(ctx context.Context, categoryID string, data model.CategoryReqMove) (res string, err *helper.ErrorStruct)
+	DeleteCategoryByID(ctx context.Context, categoryID string, params model.CategoryReqDelete) (res string, err *helper.ErrorStruct)
+	MergeCategory(ctx context.Context, categoryID string, data model.CategoryReqMerge) (res string, err *helper.ErrorStruct)
 }
 
 type CategoriesUseCaseImpl struct {
 	categoriesRepository repository.CategoriesRepository
 	shopsRepository      repository.ShopsRepository
 	productsRepository   repository.ProductsRepository
+	searchIndex          repository.ProductSearchIndex
 }
 
 func NewCategoriesUseCase(
 	categoriesRepository repository.CategoriesRepository,
 	shopsRepository repository.ShopsRepository,
 	productsRepository repository.ProductsRepository,
+	searchIndex repository.ProductSearchIndex,
 ) CategoriesUseCase {
 	return &CategoriesUseCaseImpl{
 		categoriesRepository: categoriesRepository,
 		shopsRepository:      shopsRepository,
 		productsRepository:   productsRepository,
+		searchIndex:          searchIndex,
 	}
 }
 
@@ -49,8 +58,44 @@ func (alc *CategoriesUseCaseImpl) CreateCategory(ctx context.Context, data model
 		}
 	}
 
+	if data.ParentID != nil {
+		parentID := fmt.Sprintf("%d", *data.ParentID)
+		if errRepo := alc.categoriesRepository.VerifyCategoryAvailability(ctx, parentID); errRepo != nil {
+			if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+				return res, &helper.ErrorStruct{
+					Code: fiber.StatusNotFound,
+					Err:  errors.New("kategori induk tidak ditemukan"),
+				}
+			}
+
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at VerifyCategoryAvailability: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+	}
+
+	slugSource := data.Slug
+	if slugSource == "" {
+		slugSource = data.CategoryName
+	}
+
+	slug, errSlug := alc.uniqueCategorySlug(ctx, slugSource, 0)
+	if errSlug != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at uniqueCategorySlug: %s", errSlug.Error()), errSlug)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errSlug,
+		}
+	}
+
 	resRepo, errRepo := alc.categoriesRepository.CreateCategory(ctx, entity.Category{
 		CategoryName: data.CategoryName,
+		ParentID:     data.ParentID,
+		Slug:         slug,
+		Icon:         data.Icon,
+		SortOrder:    data.SortOrder,
 	})
 	if errRepo != nil {
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetAllBooks : %s", errRepo.Error()), errRepo)
@@ -80,16 +125,46 @@ func (alc *CategoriesUseCaseImpl) GetCategories(ctx context.Context) (res []mode
 		}
 	}
 
+	counts, errRepo := alc.productsRepository.CountProductsByCategory(ctx, 0)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CountProductsByCategory: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	totals := categoryProductTotals(resRepo, counts)
 	for _, v := range resRepo {
+		productCount := totals[v.ID]
+
 		res = append(res, model.CategoryResp{
 			ID:           v.ID,
 			CategoryName: v.CategoryName,
+			ParentID:     v.ParentID,
+			Slug:         v.Slug,
+			Icon:         v.Icon,
+			SortOrder:    v.SortOrder,
+			ProductCount: &productCount,
 		})
 	}
 
 	return res, nil
 }
 
+func (alc *CategoriesUseCaseImpl) GetCategoryTree(ctx context.Context) (res []model.CategoryTreeResp, err *helper.ErrorStruct) {
+	resRepo, errRepo := alc.categoriesRepository.GetCategories(ctx)
+	if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategories: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return buildCategoryTree(resRepo), nil
+}
+
 func (alc *CategoriesUseCaseImpl) GetCategoryByID(ctx context.Context, categoryID string) (res model.CategoryResp, err *helper.ErrorStruct) {
 	resRepo, errRepo := alc.categoriesRepository.GetCategoryByID(ctx, categoryID)
 	if errRepo != nil {
@@ -110,6 +185,10 @@ func (alc *CategoriesUseCaseImpl) GetCategoryByID(ctx context.Context, categoryI
 	res = model.CategoryResp{
 		ID:           resRepo.ID,
 		CategoryName: resRepo.CategoryName,
+		ParentID:     resRepo.ParentID,
+		Slug:         resRepo.Slug,
+		Icon:         resRepo.Icon,
+		SortOrder:    resRepo.SortOrder,
 	}
 
 	return res, nil
@@ -124,7 +203,8 @@ func (alc *CategoriesUseCaseImpl) UpdateCategoryByID(ctx context.Context, catego
 		}
 	}
 
-	if errRepo := alc.categoriesRepository.VerifyCategoryAvailability(ctx, categoryID); errRepo != nil {
+	category, errRepo := alc.categoriesRepository.GetCategoryByID(ctx, categoryID)
+	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusNotFound,
@@ -132,18 +212,43 @@ func (alc *CategoriesUseCaseImpl) UpdateCategoryByID(ctx context.Context, catego
 			}
 		}
 
-		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at VerifyCategoryAvailability: %s", errRepo.Error()), errRepo)
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategoryByID: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
-	errRepo := alc.categoriesRepository.UpdateCategoryByID(ctx, categoryID, entity.Category{
-		CategoryName: data.CategoryName,
+	var slug string
+	if data.Slug != "" {
+		slug, errRepo = alc.uniqueCategorySlug(ctx, data.Slug, category.ID)
+		if errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at uniqueCategorySlug: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+	}
+
+	errTransaction := alc.categoriesRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		err = alc.categoriesRepository.UpdateCategoryByID(txCtx, categoryID, entity.Category{
+			CategoryName: data.CategoryName,
+			Slug:         slug,
+			Icon:         data.Icon,
+		})
+		if err != nil {
+			return err
+		}
+
+		if data.SortOrder == nil {
+			return nil
+		}
+
+		return alc.categoriesRepository.MoveCategory(txCtx, category.ID, category.ParentID, *data.SortOrder)
 	})
-	if errRepo != nil {
-		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateCategoryByID: %s", errRepo.Error()), errRepo)
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateCategoryByID: %s", errTransaction.Error()), errTransaction)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errors.New("gagal melakukan pembaruan"),
@@ -153,8 +258,12 @@ func (alc *CategoriesUseCaseImpl) UpdateCategoryByID(ctx context.Context, catego
 	return "updated", nil
 }
 
-func (alc *CategoriesUseCaseImpl) DeleteCategoryByID(ctx context.Context, categoryID string) (res string, err *helper.ErrorStruct) {
-	if errRepo := alc.categoriesRepository.VerifyCategoryAvailability(ctx, categoryID); errRepo != nil {
+// MoveCategory changes the parent of a category. A category cannot be moved
+// below itself or one of its descendants, that would cut the branch off the
+// tree.
+func (alc *CategoriesUseCaseImpl) MoveCategory(ctx context.Context, categoryID string, data model.CategoryReqMove) (res string, err *helper.ErrorStruct) {
+	category, errRepo := alc.categoriesRepository.GetCategoryByID(ctx, categoryID)
+	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusNotFound,
@@ -162,21 +271,244 @@ func (alc *CategoriesUseCaseImpl) DeleteCategoryByID(ctx context.Context, catego
 			}
 		}
 
-		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at VerifyCategoryAvailability: %s", errRepo.Error()), errRepo)
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategoryByID: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
-	errRepo := alc.categoriesRepository.DeleteCategoryByID(ctx, categoryID)
+	if data.ParentID != nil {
+		categories, errRepo := alc.categoriesRepository.GetCategories(ctx)
+		if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategories: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+
+		if categoryDescendantIDs(categories, *data.ParentID) == nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("kategori induk tidak ditemukan"),
+			}
+		}
+
+		for _, id := range categoryDescendantIDs(categories, category.ID) {
+			if id == *data.ParentID {
+				return res, &helper.ErrorStruct{
+					Code: fiber.StatusBadRequest,
+					Err:  errors.New("kategori tidak bisa dipindahkan ke dalam sub kategorinya sendiri"),
+				}
+			}
+		}
+	}
+
+	sortOrder := category.SortOrder
+	if data.SortOrder != nil {
+		sortOrder = *data.SortOrder
+	}
+
+	if errRepo := alc.categoriesRepository.MoveCategory(ctx, category.ID, data.ParentID, sortOrder); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at MoveCategory: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("gagal memindahkan kategori"),
+		}
+	}
+
+	return "moved", nil
+}
+
+// DeleteCategoryByID deletes a category without sub categories. Its
+// products are locked and moved to params.ReassignTo in the same
+// transaction, a category that still has products cannot be deleted
+// without it.
+func (alc *CategoriesUseCaseImpl) DeleteCategoryByID(ctx context.Context, categoryID string, params model.CategoryReqDelete) (res string, err *helper.ErrorStruct) {
+	category, errRepo := alc.categoriesRepository.GetCategoryByID(ctx, categoryID)
 	if errRepo != nil {
-		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteAddressByID: %s", errRepo.Error()), errRepo)
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("kategori tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategoryByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	children, errRepo := alc.categoriesRepository.CountChildCategories(ctx, category.ID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CountChildCategories: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
+	if children > 0 {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("kategori masih memiliki sub kategori"),
+		}
+	}
+
+	if params.ReassignTo != 0 {
+		if err := alc.verifyTargetCategory(ctx, category.ID, params.ReassignTo); err != nil {
+			return res, err
+		}
+	}
+
+	var productIDs []uint
+	errTransaction := alc.categoriesRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		productIDs, err = alc.productsRepository.GetProductIDsByCategory(txCtx, category.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(productIDs) > 0 {
+			if params.ReassignTo == 0 {
+				return errCategoryHasProducts
+			}
+
+			err = alc.productsRepository.ReassignProductsCategory(txCtx, category.ID, params.ReassignTo)
+			if err != nil {
+				return err
+			}
+		}
+
+		return alc.categoriesRepository.DeleteCategoryByID(txCtx, categoryID)
+	})
+	if errors.Is(errTransaction, errCategoryHasProducts) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errCategoryHasProducts,
+		}
+	}
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteCategoryByID: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("gagal menghapus kategori"),
+		}
+	}
+
+	for _, productID := range productIDs {
+		syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, productID)
+	}
+
 	return "deleted", nil
 }
+
+// MergeCategory moves the products and sub categories of a category into
+// data.TargetID and deletes it, all in one transaction.
+func (alc *CategoriesUseCaseImpl) MergeCategory(ctx context.Context, categoryID string, data model.CategoryReqMerge) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	category, errRepo := alc.categoriesRepository.GetCategoryByID(ctx, categoryID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("kategori tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategoryByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	if err := alc.verifyTargetCategory(ctx, category.ID, data.TargetID); err != nil {
+		return res, err
+	}
+
+	var productIDs []uint
+	errTransaction := alc.categoriesRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		productIDs, err = alc.productsRepository.GetProductIDsByCategory(txCtx, category.ID)
+		if err != nil {
+			return err
+		}
+
+		if err = alc.productsRepository.ReassignProductsCategory(txCtx, category.ID, data.TargetID); err != nil {
+			return err
+		}
+
+		if err = alc.categoriesRepository.ReparentChildCategories(txCtx, category.ID, data.TargetID); err != nil {
+			return err
+		}
+
+		return alc.categoriesRepository.DeleteCategoryByID(txCtx, categoryID)
+	})
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at MergeCategory: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("gagal menggabungkan kategori"),
+		}
+	}
+
+	for _, productID := range productIDs {
+		syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, productID)
+	}
+
+	return "merged", nil
+}
+
+// verifyTargetCategory checks that the products and sub categories of
+// categoryID can be moved to targetID: it exists and is neither the category
+// itself nor one of its descendants.
+func (alc *CategoriesUseCaseImpl) verifyTargetCategory(ctx context.Context, categoryID uint, targetID uint) *helper.ErrorStruct {
+	categories, errRepo := alc.categoriesRepository.GetCategories(ctx)
+	if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategories: %s", errRepo.Error()), errRepo)
+		return &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	if categoryDescendantIDs(categories, targetID) == nil {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusNotFound,
+			Err:  errors.New("kategori tujuan tidak ditemukan"),
+		}
+	}
+
+	for _, id := range categoryDescendantIDs(categories, categoryID) {
+		if id == targetID {
+			return &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("kategori tujuan tidak boleh kategori itu sendiri atau sub kategorinya"),
+			}
+		}
+	}
+
+	return nil
+}
+
+func (alc *CategoriesUseCaseImpl) uniqueCategorySlug(ctx context.Context, text string, categoryID uint) (string, error) {
+	base := utils.GenerateSlug(text)
+	if base == "" {
+		base = "kategori"
+	}
+
+	taken, err := alc.categoriesRepository.GetTakenSlugs(ctx, base, categoryID)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.UniqueSlug(base, taken), nil
+}
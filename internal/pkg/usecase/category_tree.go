@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+)
+
+// buildCategoryTree nests categories under their parents. categories must be
+// ordered by sort order, children keep that order. Categories whose parent
+// no longer exists are shown as roots.
+func buildCategoryTree(categories []entity.Category) []model.CategoryTreeResp {
+	exists := make(map[uint]bool, len(categories))
+	for _, v := range categories {
+		exists[v.ID] = true
+	}
+
+	children := make(map[uint][]entity.Category)
+	var roots []entity.Category
+	for _, v := range categories {
+		if v.ParentID == nil || !exists[*v.ParentID] {
+			roots = append(roots, v)
+			continue
+		}
+		children[*v.ParentID] = append(children[*v.ParentID], v)
+	}
+
+	var build func(nodes []entity.Category) []model.CategoryTreeResp
+	build = func(nodes []entity.Category) []model.CategoryTreeResp {
+		res := make([]model.CategoryTreeResp, 0, len(nodes))
+		for _, v := range nodes {
+			res = append(res, model.CategoryTreeResp{
+				ID:           v.ID,
+				CategoryName: v.CategoryName,
+				Slug:         v.Slug,
+				Icon:         v.Icon,
+				SortOrder:    v.SortOrder,
+				Children:     build(children[v.ID]),
+			})
+		}
+		return res
+	}
+
+	return build(roots)
+}
+
+// categoryDescendantIDs returns rootID followed by the ids of every category
+// below it, or nil when rootID is not in categories.
+func categoryDescendantIDs(categories []entity.Category, rootID uint) []uint {
+	children := make(map[uint][]uint)
+	found := false
+	for _, v := range categories {
+		if v.ID == rootID {
+			found = true
+		}
+		if v.ParentID != nil {
+			children[*v.ParentID] = append(children[*v.ParentID], v.ID)
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	res := []uint{rootID}
+	seen := map[uint]bool{rootID: true}
+	for i := 0; i < len(res); i++ {
+		for _, id := range children[res[i]] {
+			if !seen[id] {
+				seen[id] = true
+				res = append(res, id)
+			}
+		}
+	}
+
+	return res
+}
+
+// categoryProductTotals adds the product count of every category to itself
+// and each of its ancestors, so a category total includes its descendants.
+func categoryProductTotals(categories []entity.Category, counts map[uint]int64) map[uint]int64 {
+	parents := make(map[uint]*uint, len(categories))
+	for _, v := range categories {
+		parents[v.ID] = v.ParentID
+	}
+
+	totals := make(map[uint]int64, len(categories))
+	for _, v := range categories {
+		count := counts[v.ID]
+		if count == 0 {
+			continue
+		}
+
+		// depth guards against a cycle left behind by concurrent moves.
+		id := &v.ID
+		for depth := 0; id != nil && depth <= len(categories); depth++ {
+			if _, ok := parents[*id]; !ok {
+				break
+			}
+			totals[*id] += count
+			id = parents[*id]
+		}
+	}
+
+	return totals
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"backend-evermos/internal/infrastructure/storage"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/utils"
+	"bytes"
+	"context"
+	"mime/multipart"
+	"path"
+	"strings"
+)
+
+const (
+	productUploadDir = "products"
+	shopUploadDir    = "shops"
+)
+
+// saveImage validates an uploaded image and stores it together with its
+// renditions under directory. It returns the stored file name.
+func saveImage(ctx context.Context, files storage.Storage, directory string, file *multipart.FileHeader, maxSize int64) (string, error) {
+	images, err := utils.ProcessImage(file, maxSize)
+	if err != nil {
+		return "", err
+	}
+
+	for i, img := range images {
+		if err := files.Put(ctx, path.Join(directory, img.FileName), bytes.NewReader(img.Data), img.ContentType); err != nil {
+			for _, stored := range images[:i] {
+				_ = files.Delete(ctx, path.Join(directory, stored.FileName))
+			}
+			return "", err
+		}
+	}
+
+	return images[0].FileName, nil
+}
+
+// removeImage deletes an image stored by saveImage together with its
+// renditions.
+func removeImage(ctx context.Context, files storage.Storage, directory string, fileName string) error {
+	if fileName == "" {
+		return nil
+	}
+
+	if err := files.Delete(ctx, path.Join(directory, fileName)); err != nil {
+		return err
+	}
+
+	for _, rendition := range utils.ImageRenditions {
+		if err := files.Delete(ctx, path.Join(directory, utils.RenditionFileName(fileName, rendition.Name))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileURL returns the public URL of a stored file. Values that are already
+// absolute URLs are returned unchanged.
+func fileURL(files storage.Storage, directory string, fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+
+	if isAbsoluteURL(fileName) {
+		return fileName
+	}
+
+	return files.URL(path.Join(directory, fileName))
+}
+
+func isAbsoluteURL(fileName string) bool {
+	return strings.HasPrefix(fileName, "http://") || strings.HasPrefix(fileName, "https://")
+}
+
+// imageRenditionsResp returns the URLs of the renditions of a stored image.
+// Images saved as absolute URLs have no renditions.
+func imageRenditionsResp(files storage.Storage, directory string, fileName string) *model.ImageRenditionsResp {
+	if fileName == "" || isAbsoluteURL(fileName) {
+		return nil
+	}
+
+	return &model.ImageRenditionsResp{
+		Thumbnail: fileURL(files, directory, utils.RenditionFileName(fileName, "thumbnail")),
+		Medium:    fileURL(files, directory, utils.RenditionFileName(fileName, "medium")),
+		Large:     fileURL(files, directory, utils.RenditionFileName(fileName, "large")),
+	}
+}
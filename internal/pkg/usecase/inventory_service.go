@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/repository"
+	"backend-evermos/internal/utils"
+	"context"
+	"time"
+)
+
+// InventoryService is the only place stock is changed. Every change is
+// written to the stock movement ledger and may raise a stock alert, so
+// callers run it inside the transaction that causes the change.
+type InventoryService interface {
+	ApplyStockChange(ctx context.Context, change StockChange) (err error)
+	SetStock(ctx context.Context, change StockChange, stock int) (err error)
+	GetStockMovements(ctx context.Context, params entity.FilterStockMovements) (res []entity.StockMovement, err error)
+}
+
+// StockChange describes a stock movement of a product, or of one of its
+// variants when VariantID is set. The stock of a product with variants is
+// the sum of its variants, so a variant movement moves the product too.
+type StockChange struct {
+	ProductID uint
+	VariantID *uint
+	Delta     int
+	Reason    string
+	TrxID     *uint
+	ActorID   *uint
+}
+
+type InventoryServiceImpl struct {
+	productsRepository       repository.ProductsRepository
+	variantsRepository       repository.ProductVariantsRepository
+	stockMovementsRepository repository.StockMovementsRepository
+	stockAlertsRepository    repository.StockAlertsRepository
+}
+
+func NewInventoryService(
+	productsRepository repository.ProductsRepository,
+	variantsRepository repository.ProductVariantsRepository,
+	stockMovementsRepository repository.StockMovementsRepository,
+	stockAlertsRepository repository.StockAlertsRepository,
+) InventoryService {
+	return &InventoryServiceImpl{
+		productsRepository:       productsRepository,
+		variantsRepository:       variantsRepository,
+		stockMovementsRepository: stockMovementsRepository,
+		stockAlertsRepository:    stockAlertsRepository,
+	}
+}
+
+// ApplyStockChange adds change.Delta to the stock. It returns
+// repository.ErrInsufficientStock when the stock would drop below zero.
+func (s *InventoryServiceImpl) ApplyStockChange(ctx context.Context, change StockChange) (err error) {
+	if change.Delta == 0 {
+		return nil
+	}
+
+	var variantStock *int
+	if change.VariantID != nil {
+		stock, err := s.variantsRepository.AdjustVariantStock(ctx, *change.VariantID, change.Delta)
+		if err != nil {
+			return err
+		}
+		variantStock = &stock
+	}
+
+	product, err := s.productsRepository.AdjustProductStock(ctx, change.ProductID, change.Delta)
+	if err != nil {
+		return err
+	}
+
+	previous := product.Stock - change.Delta
+	if previous == 0 && product.Stock > 0 {
+		if err := s.markRestocked(ctx, product.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.stockMovementsRepository.CreateStockMovement(ctx, entity.StockMovement{
+		ProductID:         change.ProductID,
+		VariantID:         change.VariantID,
+		Delta:             change.Delta,
+		StockAfter:        product.Stock,
+		VariantStockAfter: variantStock,
+		Reason:            change.Reason,
+		TrxID:             change.TrxID,
+		ActorID:           change.ActorID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.raiseStockAlert(ctx, product, previous)
+}
+
+// markRestocked records that the product is back in stock. The first stock
+// of a new product, before any movement was recorded, is not a restock.
+func (s *InventoryServiceImpl) markRestocked(ctx context.Context, productID uint) (err error) {
+	movements, err := s.stockMovementsRepository.CountStockMovements(ctx, productID)
+	if err != nil || movements == 0 {
+		return err
+	}
+
+	return s.productsRepository.MarkProductRestocked(ctx, productID, time.Now())
+}
+
+// raiseStockAlert records an alert when the stock of product crossed its
+// low stock threshold or reached zero coming from previous. Stock that
+// stays below the threshold does not raise the alert again.
+func (s *InventoryServiceImpl) raiseStockAlert(ctx context.Context, product entity.Product, previous int) (err error) {
+	var alertType string
+	switch {
+	case product.Stock == 0 && previous > 0:
+		alertType = entity.StockAlertOutOfStock
+	case product.Stock <= product.LowStockThreshold && previous > product.LowStockThreshold:
+		alertType = entity.StockAlertLowStock
+	default:
+		return nil
+	}
+
+	_, err = s.stockAlertsRepository.CreateStockAlert(ctx, entity.StockAlert{
+		ShopID:    product.ShopID,
+		ProductID: product.ID,
+		Type:      alertType,
+		Stock:     product.Stock,
+		Threshold: product.LowStockThreshold,
+	})
+
+	return err
+}
+
+// SetStock moves the stock to an absolute value, recording the difference
+// as the movement. change.Delta is ignored.
+func (s *InventoryServiceImpl) SetStock(ctx context.Context, change StockChange, stock int) (err error) {
+	var current int
+	if change.VariantID != nil {
+		variant, err := s.variantsRepository.GetVariantByID(ctx, change.ProductID, *change.VariantID)
+		if err != nil {
+			return err
+		}
+		current = variant.Stock
+	} else {
+		product, err := s.productsRepository.GetProductStock(ctx, change.ProductID)
+		if err != nil {
+			return err
+		}
+		current = product.Stock
+	}
+
+	change.Delta = stock - current
+	return s.ApplyStockChange(ctx, change)
+}
+
+func (s *InventoryServiceImpl) GetStockMovements(ctx context.Context, params entity.FilterStockMovements) (res []entity.StockMovement, err error) {
+	return s.stockMovementsRepository.GetStockMovements(ctx, params)
+}
+
+// stockActorID converts the id of the user causing a stock movement, nil
+// when it is not a valid id.
+func stockActorID(userID string) *uint {
+	id, err := utils.ConvertStringToUint(userID)
+	if err != nil || id == 0 {
+		return nil
+	}
+
+	return &id
+}
@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/mailer"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ModerationUseCase lets admins hide bad sellers and products. The seller
+// is emailed every decision together with its reason.
+type ModerationUseCase interface {
+	SuspendShop(ctx context.Context, shopID string, data model.ModerationReqCreate) (res string, err *helper.ErrorStruct)
+	UnsuspendShop(ctx context.Context, shopID string, data model.ModerationReqLift) (res string, err *helper.ErrorStruct)
+	TakedownProduct(ctx context.Context, productID string, data model.ModerationReqCreate) (res string, err *helper.ErrorStruct)
+	RestoreProduct(ctx context.Context, productID string, data model.ModerationReqLift) (res string, err *helper.ErrorStruct)
+}
+
+type ModerationUseCaseImpl struct {
+	shopsRepository    repository.ShopsRepository
+	productsRepository repository.ProductsRepository
+	usersRepository    repository.UsersRepository
+	searchIndex        repository.ProductSearchIndex
+	mailer             mailer.Mailer
+}
+
+func NewModerationUseCase(
+	shopsRepository repository.ShopsRepository,
+	productsRepository repository.ProductsRepository,
+	usersRepository repository.UsersRepository,
+	searchIndex repository.ProductSearchIndex,
+	mailer mailer.Mailer,
+) ModerationUseCase {
+	return &ModerationUseCaseImpl{
+		shopsRepository:    shopsRepository,
+		productsRepository: productsRepository,
+		usersRepository:    usersRepository,
+		searchIndex:        searchIndex,
+		mailer:             mailer,
+	}
+}
+
+func (alc *ModerationUseCaseImpl) SuspendShop(ctx context.Context, shopID string, data model.ModerationReqCreate) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	shop, err := alc.getShop(ctx, shopID)
+	if err != nil {
+		return res, err
+	}
+
+	if shop.SuspendedAt != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("toko sudah ditangguhkan"),
+		}
+	}
+
+	now := time.Now()
+	if err := alc.updateShopSuspension(ctx, shop, &now, data.Reason); err != nil {
+		return res, err
+	}
+
+	alc.notifySeller(ctx, shop,
+		fmt.Sprintf("Toko %s ditangguhkan", shop.ShopName),
+		fmt.Sprintf("Toko %s ditangguhkan oleh admin. Selama ditangguhkan, toko dan produknya tidak tampil dan tidak dapat dibeli.", shop.ShopName),
+		data.Reason,
+	)
+
+	return "suspended", nil
+}
+
+func (alc *ModerationUseCaseImpl) UnsuspendShop(ctx context.Context, shopID string, data model.ModerationReqLift) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	shop, err := alc.getShop(ctx, shopID)
+	if err != nil {
+		return res, err
+	}
+
+	if shop.SuspendedAt == nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("toko tidak sedang ditangguhkan"),
+		}
+	}
+
+	if err := alc.updateShopSuspension(ctx, shop, nil, ""); err != nil {
+		return res, err
+	}
+
+	alc.notifySeller(ctx, shop,
+		fmt.Sprintf("Penangguhan toko %s dicabut", shop.ShopName),
+		fmt.Sprintf("Penangguhan toko %s telah dicabut, toko dan produknya kembali tampil.", shop.ShopName),
+		data.Reason,
+	)
+
+	return "unsuspended", nil
+}
+
+func (alc *ModerationUseCaseImpl) TakedownProduct(ctx context.Context, productID string, data model.ModerationReqCreate) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	product, err := alc.getProduct(ctx, productID)
+	if err != nil {
+		return res, err
+	}
+
+	if product.TakenDownAt != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("produk sudah diturunkan"),
+		}
+	}
+
+	now := time.Now()
+	if err := alc.updateProductTakedown(ctx, product, &now, data.Reason); err != nil {
+		return res, err
+	}
+
+	alc.notifySeller(ctx, product.Shop,
+		fmt.Sprintf("Produk %s diturunkan", product.ProductName),
+		fmt.Sprintf("Produk %s di toko %s diturunkan oleh admin dan tidak dapat dibeli.", product.ProductName, product.Shop.ShopName),
+		data.Reason,
+	)
+
+	return "taken down", nil
+}
+
+func (alc *ModerationUseCaseImpl) RestoreProduct(ctx context.Context, productID string, data model.ModerationReqLift) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	product, err := alc.getProduct(ctx, productID)
+	if err != nil {
+		return res, err
+	}
+
+	if product.TakenDownAt == nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("produk tidak sedang diturunkan"),
+		}
+	}
+
+	if err := alc.updateProductTakedown(ctx, product, nil, ""); err != nil {
+		return res, err
+	}
+
+	alc.notifySeller(ctx, product.Shop,
+		fmt.Sprintf("Produk %s dipulihkan", product.ProductName),
+		fmt.Sprintf("Produk %s di toko %s telah dipulihkan dan kembali tampil.", product.ProductName, product.Shop.ShopName),
+		data.Reason,
+	)
+
+	return "restored", nil
+}
+
+func (alc *ModerationUseCaseImpl) getShop(ctx context.Context, shopID string) (res entity.Shop, err *helper.ErrorStruct) {
+	res, errRepo := alc.shopsRepository.GetShopByID(ctx, shopID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return res, nil
+}
+
+func (alc *ModerationUseCaseImpl) getProduct(ctx context.Context, productID string) (res entity.Product, err *helper.ErrorStruct) {
+	res, errRepo := alc.productsRepository.GetProductByID(ctx, productID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("produk tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return res, nil
+}
+
+// updateShopSuspension also refreshes the search documents of the shop
+// products, which drop out of the index while the shop is suspended.
+func (alc *ModerationUseCaseImpl) updateShopSuspension(ctx context.Context, shop entity.Shop, suspendedAt *time.Time, reason string) *helper.ErrorStruct {
+	if errRepo := alc.shopsRepository.UpdateShopSuspension(ctx, shop.ID, suspendedAt, reason); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateShopSuspension: %s", errRepo.Error()), errRepo)
+		return &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("gagal memperbarui status toko"),
+		}
+	}
+
+	productIDs, errRepo := alc.productsRepository.GetProductIDsByShop(ctx, shop.ID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductIDsByShop: %s", errRepo.Error()), errRepo)
+		return nil
+	}
+
+	for _, id := range productIDs {
+		syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, id)
+	}
+
+	return nil
+}
+
+func (alc *ModerationUseCaseImpl) updateProductTakedown(ctx context.Context, product entity.Product, takenDownAt *time.Time, reason string) *helper.ErrorStruct {
+	if errRepo := alc.productsRepository.UpdateProductTakedown(ctx, product.ID, takenDownAt, reason); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateProductTakedown: %s", errRepo.Error()), errRepo)
+		return &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("gagal memperbarui status produk"),
+		}
+	}
+
+	syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, product.ID)
+
+	return nil
+}
+
+// notifySeller emails the owner of shop. The decision is already saved, so
+// failures are only logged.
+func (alc *ModerationUseCaseImpl) notifySeller(ctx context.Context, shop entity.Shop, subject string, message string, reason string) {
+	owner, errRepo := alc.usersRepository.GetUserByID(ctx, fmt.Sprintf("%d", shop.UserID))
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return
+	}
+
+	if owner.Email == "" {
+		return
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Halo %s,\n\n%s\n", owner.Name, message)
+	if reason != "" {
+		fmt.Fprintf(&body, "\nAlasan: %s\n", reason)
+	}
+
+	errSend := alc.mailer.Send(ctx, mailer.Message{
+		To:      []string{owner.Email},
+		Subject: subject,
+		Body:    body.String(),
+	})
+	if errSend != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at Send: %s", errSend.Error()), errSend)
+	}
+}
+
+// productModerated reports whether the product is hidden by a moderation
+// decision, on the product itself or on its shop. product.Shop must be
+// loaded.
+func productModerated(product entity.Product) bool {
+	return product.TakenDownAt != nil || product.Shop.SuspendedAt != nil
+}
@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func (alc *ProductsUseCaseImpl) AddProductPhotos(ctx context.Context, userID string, productID string, files []*multipart.FileHeader) (res []uint, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	if len(files) == 0 {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("foto produk harus diisi"),
+		}
+	}
+
+	if len(product.Images)+len(files) > alc.photoLimits.Max {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  fmt.Errorf("jumlah foto produk maksimal %d", alc.photoLimits.Max),
+		}
+	}
+
+	var photoURLs []string
+	for _, fileHeader := range files {
+		photoURL, errSave := saveImage(ctx, alc.files, productUploadDir, fileHeader, alc.photoLimits.MaxSize)
+		if errSave != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errSave,
+			}
+		}
+		photoURLs = append(photoURLs, photoURL)
+	}
+
+	nextPosition := 0
+	for _, img := range product.Images {
+		if img.Position >= nextPosition {
+			nextPosition = img.Position + 1
+		}
+	}
+
+	errTransaction := alc.productImagesRepository.WithinTransaction(ctx, func(txCtx context.Context) error {
+		for i, photoURL := range photoURLs {
+			imageID, errRepo := alc.productImagesRepository.CreateProductImage(txCtx, entity.ProductImage{
+				ProductID: product.ID,
+				PhotoURL:  photoURL,
+				Position:  nextPosition + i,
+				IsPrimary: len(product.Images) == 0 && i == 0,
+			})
+			if errRepo != nil {
+				return errRepo
+			}
+			res = append(res, imageID)
+		}
+
+		return nil
+	})
+	if errTransaction != nil {
+		for _, photoURL := range photoURLs {
+			_ = removeImage(ctx, alc.files, productUploadDir, photoURL)
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
+		return nil, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal menambahkan foto produk"),
+		}
+	}
+
+	return res, nil
+}
+
+func (alc *ProductsUseCaseImpl) ReplaceProductPhoto(ctx context.Context, userID string, productID string, photoID string, file *multipart.FileHeader) (res string, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	image, err := alc.getProductPhoto(ctx, product.ID, photoID)
+	if err != nil {
+		return res, err
+	}
+
+	photoURL, errSave := saveImage(ctx, alc.files, productUploadDir, file, alc.photoLimits.MaxSize)
+	if errSave != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errSave,
+		}
+	}
+
+	errRepo := alc.productImagesRepository.UpdateProductImage(ctx, photoID, entity.ProductImage{
+		PhotoURL: photoURL,
+	})
+	if errRepo != nil {
+		_ = removeImage(ctx, alc.files, productUploadDir, photoURL)
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateProductImage: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengganti foto produk"),
+		}
+	}
+
+	_ = removeImage(ctx, alc.files, productUploadDir, image.PhotoURL)
+
+	return "updated", nil
+}
+
+func (alc *ProductsUseCaseImpl) DeleteProductPhoto(ctx context.Context, userID string, productID string, photoID string) (res string, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	image, err := alc.getProductPhoto(ctx, product.ID, photoID)
+	if err != nil {
+		return res, err
+	}
+
+	if len(product.Images)-1 < alc.photoLimits.Min {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  fmt.Errorf("jumlah foto produk minimal %d", alc.photoLimits.Min),
+		}
+	}
+
+	errTransaction := alc.productImagesRepository.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if errRepo := alc.productImagesRepository.DeleteProductImage(txCtx, image.ID); errRepo != nil {
+			return errRepo
+		}
+
+		if !image.IsPrimary {
+			return nil
+		}
+
+		// Promote the next photo in order so the product keeps a primary photo.
+		for _, img := range product.Images {
+			if img.ID != image.ID {
+				return alc.productImagesRepository.SetPrimaryImage(txCtx, product.ID, img.ID)
+			}
+		}
+
+		return nil
+	})
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal menghapus foto produk"),
+		}
+	}
+
+	_ = removeImage(ctx, alc.files, productUploadDir, image.PhotoURL)
+
+	return "deleted", nil
+}
+
+func (alc *ProductsUseCaseImpl) ReorderProductPhotos(ctx context.Context, userID string, productID string, data model.ProductPhotosReorderReq) (res string, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	owned := make(map[uint]bool, len(product.Images))
+	for _, img := range product.Images {
+		owned[img.ID] = true
+	}
+
+	seen := make(map[uint]bool, len(data.PhotoIDs))
+	for _, id := range data.PhotoIDs {
+		if !owned[id] || seen[id] {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("urutan foto tidak valid"),
+			}
+		}
+		seen[id] = true
+	}
+
+	if len(seen) != len(owned) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("urutan foto harus memuat semua foto produk"),
+		}
+	}
+
+	errTransaction := alc.productImagesRepository.WithinTransaction(ctx, func(txCtx context.Context) error {
+		for position, id := range data.PhotoIDs {
+			if errRepo := alc.productImagesRepository.UpdateImagePosition(txCtx, id, position); errRepo != nil {
+				return errRepo
+			}
+		}
+
+		return nil
+	})
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengurutkan foto produk"),
+		}
+	}
+
+	return "updated", nil
+}
+
+func (alc *ProductsUseCaseImpl) SetPrimaryProductPhoto(ctx context.Context, userID string, productID string, photoID string) (res string, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	image, err := alc.getProductPhoto(ctx, product.ID, photoID)
+	if err != nil {
+		return res, err
+	}
+
+	if errRepo := alc.productImagesRepository.SetPrimaryImage(ctx, product.ID, image.ID); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at SetPrimaryImage: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengubah foto utama"),
+		}
+	}
+
+	return "updated", nil
+}
+
+// getOwnedProduct loads a product and checks that it belongs to the shop of
+// userID.
+func (alc *ProductsUseCaseImpl) getOwnedProduct(ctx context.Context, userID string, productID string) (res entity.Product, err *helper.ErrorStruct) {
+	res, errRepo := alc.productsRepository.GetProductByID(ctx, productID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("produk tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	resShopRepo, errRepo := alc.shopsRepository.GetShopByUserID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByUserID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	if res.ShopID != resShopRepo.ID {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusForbidden,
+			Err:  errors.New("anda tidak berhak mengakses resource ini"),
+		}
+	}
+
+	return res, nil
+}
+
+func (alc *ProductsUseCaseImpl) getProductPhoto(ctx context.Context, productID uint, photoID string) (res entity.ProductImage, err *helper.ErrorStruct) {
+	res, errRepo := alc.productImagesRepository.GetImageByID(ctx, productID, photoID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("foto tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetImageByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return res, nil
+}
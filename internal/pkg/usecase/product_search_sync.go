@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// syncProductIndex refreshes the search document of a product after it was
+// written. Failures are only logged, a full reindex repairs a stale index.
+func syncProductIndex(ctx context.Context, productsRepository repository.ProductsRepository, searchIndex repository.ProductSearchIndex, productID uint) {
+	product, errRepo := productsRepository.GetProductByID(ctx, fmt.Sprintf("%d", productID))
+	if errors.Is(errRepo, gorm.ErrRecordNotFound) || (errRepo == nil && productModerated(product)) {
+		if errIndex := searchIndex.DeleteProduct(ctx, productID); errIndex != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteProduct: %s", errIndex.Error()), errIndex)
+		}
+		return
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductByID: %s", errRepo.Error()), errRepo)
+		return
+	}
+
+	doc, errDoc := productToSearchDocument(ctx, productsRepository, product)
+	if errDoc != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at productToSearchDocument: %s", errDoc.Error()), errDoc)
+		return
+	}
+
+	if errIndex := searchIndex.IndexProducts(ctx, []entity.ProductSearchDocument{doc}); errIndex != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at IndexProducts: %s", errIndex.Error()), errIndex)
+	}
+}
+
+func productToSearchDocument(ctx context.Context, productsRepository repository.ProductsRepository, product entity.Product) (res entity.ProductSearchDocument, err error) {
+	totalSold, err := productsRepository.GetTotalSold(ctx, product.ID)
+	if err != nil {
+		return res, err
+	}
+
+	price, _ := strconv.Atoi(product.ConsumerPrice)
+
+	var categoryID uint
+	if product.CategoryID != nil {
+		categoryID = *product.CategoryID
+	}
+
+	return entity.ProductSearchDocument{
+		ID:            product.ID,
+		ProductName:   product.ProductName,
+		Description:   product.Description,
+		ShopID:        product.ShopID,
+		CategoryID:    categoryID,
+		ConsumerPrice: price,
+		Stock:         product.Stock,
+		TotalSold:     totalSold,
+		CreatedAt:     product.CreatedAt.Unix(),
+	}, nil
+}
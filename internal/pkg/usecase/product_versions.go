@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// recordProductVersion snapshots the current content of a product and
+// returns the id of its version. The latest version is reused when the
+// content did not change, otherwise a new version edited by editorID is
+// written. Callers run it inside the transaction that changed the product.
+func recordProductVersion(ctx context.Context, productsRepository repository.ProductsRepository, productLogsRepository repository.ProductLogsRepository, productID uint, editorID *uint) (res uint, err error) {
+	product, err := productsRepository.GetProductByID(ctx, fmt.Sprintf("%d", productID))
+	if err != nil {
+		return res, err
+	}
+
+	snapshot := productSnapshot(product)
+
+	latest, err := productLogsRepository.GetLatestProductLog(ctx, productID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return res, err
+	}
+
+	if err == nil && latest.ContentHash == snapshot.ContentHash {
+		return latest.ID, nil
+	}
+
+	snapshot.Version = latest.Version + 1
+	snapshot.EditorID = editorID
+
+	return productLogsRepository.CreateProductLogs(ctx, snapshot)
+}
+
+func productSnapshot(product entity.Product) entity.ProductLog {
+	variants := make([]entity.ProductLogVariant, 0, len(product.Variants))
+	for _, v := range product.Variants {
+		variants = append(variants, entity.ProductLogVariant{
+			ID:            v.ID,
+			SKU:           v.SKU,
+			Options:       v.Options,
+			ResellerPrice: v.ResellerPrice,
+			ConsumerPrice: v.ConsumerPrice,
+		})
+	}
+	sort.Slice(variants, func(i, j int) bool { return variants[i].ID < variants[j].ID })
+
+	snapshot := entity.ProductLog{
+		ProductID:     product.ID,
+		ProductName:   product.ProductName,
+		Slug:          product.Slug,
+		ResellerPrice: product.ResellerPrice,
+		ConsumerPrice: product.ConsumerPrice,
+		Description:   product.Description,
+		ShopID:        product.ShopID,
+		CategoryID:    product.CategoryID,
+		Variants:      variants,
+	}
+	snapshot.ContentHash = productContentHash(snapshot)
+
+	return snapshot
+}
+
+// productContentHash hashes the fields of a snapshot that make up the
+// content of a product. Stock is not content, selling does not create a
+// version.
+func productContentHash(snapshot entity.ProductLog) string {
+	content, _ := json.Marshal(struct {
+		ProductName   string
+		Slug          string
+		ResellerPrice string
+		ConsumerPrice string
+		Description   string
+		ShopID        uint
+		CategoryID    *uint
+		Variants      []entity.ProductLogVariant
+	}{
+		ProductName:   snapshot.ProductName,
+		Slug:          snapshot.Slug,
+		ResellerPrice: snapshot.ResellerPrice,
+		ConsumerPrice: snapshot.ConsumerPrice,
+		Description:   snapshot.Description,
+		ShopID:        snapshot.ShopID,
+		CategoryID:    snapshot.CategoryID,
+		Variants:      snapshot.Variants,
+	})
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// productVersionChanges lists the fields that differ between two versions.
+// Variant fields are named after the variant SKU, e.g.
+// "varian[KAOS-M].harga_konsumen". A nil previous version is the first
+// one, every field set in it counts as a change.
+func productVersionChanges(previous *entity.ProductLog, current entity.ProductLog) []model.ProductFieldChange {
+	if previous == nil {
+		previous = &entity.ProductLog{}
+	}
+
+	changes := []model.ProductFieldChange{}
+	addChange := func(field string, before, after interface{}) {
+		changes = append(changes, model.ProductFieldChange{
+			Field:  field,
+			Before: before,
+			After:  after,
+		})
+	}
+
+	if previous.ProductName != current.ProductName {
+		addChange("nama_produk", previous.ProductName, current.ProductName)
+	}
+	if previous.Slug != current.Slug {
+		addChange("slug", previous.Slug, current.Slug)
+	}
+	if previous.ResellerPrice != current.ResellerPrice {
+		addChange("harga_reseler", previous.ResellerPrice, current.ResellerPrice)
+	}
+	if previous.ConsumerPrice != current.ConsumerPrice {
+		addChange("harga_konsumen", previous.ConsumerPrice, current.ConsumerPrice)
+	}
+	if previous.Description != current.Description {
+		addChange("deskripsi", previous.Description, current.Description)
+	}
+	if !equalUintPtr(previous.CategoryID, current.CategoryID) {
+		addChange("category_id", previous.CategoryID, current.CategoryID)
+	}
+
+	previousVariants := make(map[uint]entity.ProductLogVariant)
+	for _, v := range previous.Variants {
+		previousVariants[v.ID] = v
+	}
+
+	for _, v := range current.Variants {
+		field := fmt.Sprintf("varian[%s]", v.SKU)
+
+		old, ok := previousVariants[v.ID]
+		delete(previousVariants, v.ID)
+		if !ok {
+			addChange(field, nil, productLogVariantToResp(v))
+			continue
+		}
+
+		if old.SKU != v.SKU {
+			addChange(field+".sku", old.SKU, v.SKU)
+		}
+		if !equalOptions(old.Options, v.Options) {
+			addChange(field+".opsi", old.Options, v.Options)
+		}
+		if old.ResellerPrice != v.ResellerPrice {
+			addChange(field+".harga_reseler", old.ResellerPrice, v.ResellerPrice)
+		}
+		if old.ConsumerPrice != v.ConsumerPrice {
+			addChange(field+".harga_konsumen", old.ConsumerPrice, v.ConsumerPrice)
+		}
+	}
+
+	for _, v := range previous.Variants {
+		if _, removed := previousVariants[v.ID]; removed {
+			addChange(fmt.Sprintf("varian[%s]", v.SKU), productLogVariantToResp(v), nil)
+		}
+	}
+
+	return changes
+}
+
+func productLogVariantToResp(v entity.ProductLogVariant) model.ProductLogVariant {
+	return model.ProductLogVariant{
+		ID:            v.ID,
+		SKU:           v.SKU,
+		Options:       v.Options,
+		ResellerPrice: v.ResellerPrice,
+		ConsumerPrice: v.ConsumerPrice,
+	}
+}
+
+func equalUintPtr(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func equalOptions(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
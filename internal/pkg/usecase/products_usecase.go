@@ -2,27 +2,51 @@ package usecase
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/repository"
 	"backend-evermos/internal/utils"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"mime/multipart"
-	"os"
+	"sort"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
 
 type ProductsUseCase interface {
-	CreateProduct(ctx context.Context, userID string, data model.ProductReqCreate, files []*multipart.FileHeader) (res uint, err *helper.ErrorStruct)
-	GetAllProducts(ctx context.Context, params model.ProductsFilter) (res model.FilteredData, err *helper.ErrorStruct)
+	CreateProduct(ctx context.Context, userID string, data model.ProductReqCreate, files []*multipart.FileHeader, variantFiles []*multipart.FileHeader) (res uint, err *helper.ErrorStruct)
+	GetAllProducts(ctx context.Context, userID string, params model.ProductsFilter) (res model.FilteredData, err *helper.ErrorStruct)
 	GetProductByID(ctx context.Context, productID string) (res model.ProductResp, err *helper.ErrorStruct)
-	UpdateProductByID(ctx context.Context, userID string, productID string, data model.ProductReqUpdate, files []*multipart.FileHeader) (res string, err *helper.ErrorStruct)
+	GetProductBySlug(ctx context.Context, shopSlug string, slug string) (res model.ProductResp, redirectSlug string, err *helper.ErrorStruct)
+	UpdateProductByID(ctx context.Context, userID string, productID string, data model.ProductReqUpdate, files []*multipart.FileHeader, variantFiles []*multipart.FileHeader) (res string, err *helper.ErrorStruct)
 	DeleteProductByID(ctx context.Context, userID string, productID string) (res string, err *helper.ErrorStruct)
+	ReindexProducts(ctx context.Context) (res int, err *helper.ErrorStruct)
+	GetStockHistory(ctx context.Context, userID string, productID string, params model.StockMovementsFilter) (res model.FilteredData, err *helper.ErrorStruct)
+	GetProductHistory(ctx context.Context, userID string, productID string, params model.ProductHistoryFilter) (res model.FilteredData, err *helper.ErrorStruct)
+
+	// Photos
+	AddProductPhotos(ctx context.Context, userID string, productID string, files []*multipart.FileHeader) (res []uint, err *helper.ErrorStruct)
+	ReplaceProductPhoto(ctx context.Context, userID string, productID string, photoID string, file *multipart.FileHeader) (res string, err *helper.ErrorStruct)
+	DeleteProductPhoto(ctx context.Context, userID string, productID string, photoID string) (res string, err *helper.ErrorStruct)
+	ReorderProductPhotos(ctx context.Context, userID string, productID string, data model.ProductPhotosReorderReq) (res string, err *helper.ErrorStruct)
+	SetPrimaryProductPhoto(ctx context.Context, userID string, productID string, photoID string) (res string, err *helper.ErrorStruct)
+}
+
+var errProductSlugTaken = errors.New("slug produk sudah digunakan, silakan coba lagi")
+
+// ProductPhotoLimits bounds how many photos a product may have and how
+// large each uploaded photo may be in bytes.
+type ProductPhotoLimits struct {
+	Min     int
+	Max     int
+	MaxSize int64
 }
 
 type ProductsUseCaseImpl struct {
@@ -30,6 +54,14 @@ type ProductsUseCaseImpl struct {
 	shopsRepository         repository.ShopsRepository
 	productImagesRepository repository.ProductImagesRepository
 	categoriesRepository    repository.CategoriesRepository
+	wishlistsRepository     repository.WishlistsRepository
+	variantsRepository      repository.ProductVariantsRepository
+	searchIndex             repository.ProductSearchIndex
+	slugRedirectsRepository repository.ProductSlugRedirectsRepository
+	productLogsRepository   repository.ProductLogsRepository
+	photoLimits             ProductPhotoLimits
+	files                   storage.Storage
+	inventory               InventoryService
 }
 
 func NewProductsUseCase(
@@ -37,16 +69,32 @@ func NewProductsUseCase(
 	shopsRepository repository.ShopsRepository,
 	productImagesRepository repository.ProductImagesRepository,
 	categoriesRepository repository.CategoriesRepository,
+	wishlistsRepository repository.WishlistsRepository,
+	variantsRepository repository.ProductVariantsRepository,
+	searchIndex repository.ProductSearchIndex,
+	slugRedirectsRepository repository.ProductSlugRedirectsRepository,
+	productLogsRepository repository.ProductLogsRepository,
+	photoLimits ProductPhotoLimits,
+	files storage.Storage,
+	inventory InventoryService,
 ) ProductsUseCase {
 	return &ProductsUseCaseImpl{
 		productsRepository:      productsRepository,
 		shopsRepository:         shopsRepository,
 		productImagesRepository: productImagesRepository,
 		categoriesRepository:    categoriesRepository,
+		wishlistsRepository:     wishlistsRepository,
+		variantsRepository:      variantsRepository,
+		searchIndex:             searchIndex,
+		slugRedirectsRepository: slugRedirectsRepository,
+		productLogsRepository:   productLogsRepository,
+		photoLimits:             photoLimits,
+		files:                   files,
+		inventory:               inventory,
 	}
 }
 
-func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string, data model.ProductReqCreate, files []*multipart.FileHeader) (res uint, err *helper.ErrorStruct) {
+func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string, data model.ProductReqCreate, files []*multipart.FileHeader, variantFiles []*multipart.FileHeader) (res uint, err *helper.ErrorStruct) {
 	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
@@ -54,6 +102,21 @@ func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string
 		}
 	}
 
+	options, variants, errParse := parseProductVariants(data.Options, data.Variants, len(variantFiles))
+	if errParse != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errParse,
+		}
+	}
+
+	if len(files) < alc.photoLimits.Min || len(files) > alc.photoLimits.Max {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  fmt.Errorf("jumlah foto produk harus antara %d dan %d", alc.photoLimits.Min, alc.photoLimits.Max),
+		}
+	}
+
 	var shopID uint
 	resRepo, errRepo := alc.shopsRepository.GetShopByUserID(ctx, userID)
 	if errRepo != nil {
@@ -90,10 +153,24 @@ func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string
 		}
 	}
 
-	uploadDir := "files/products"
+	slugSource := data.Slug
+	if slugSource == "" {
+		slugSource = data.ProductName
+	}
+
+	slug, errSlug := alc.uniqueProductSlug(ctx, resRepo.ID, slugSource, 0)
+	if errSlug != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetTakenSlugs: %s", errSlug.Error()), errSlug)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errSlug,
+		}
+	}
+
+	uploadDir := productUploadDir
 	var photoURLs []string
 	for _, fileHeader := range files {
-		photoURL, err := utils.SaveFileToDisk(fileHeader, uploadDir)
+		photoURL, err := saveImage(ctx, alc.files, uploadDir, fileHeader, alc.photoLimits.MaxSize)
 		if err != nil {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusBadRequest,
@@ -103,29 +180,80 @@ func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string
 		photoURLs = append(photoURLs, photoURL)
 	}
 
+	var variantPhotoURLs []string
+	for _, fileHeader := range variantFiles {
+		photoURL, err := saveImage(ctx, alc.files, uploadDir, fileHeader, alc.photoLimits.MaxSize)
+		if err != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  err,
+			}
+		}
+		variantPhotoURLs = append(variantPhotoURLs, photoURL)
+	}
+
+	actorID := stockActorID(userID)
+
 	var productID uint
-	errTransaction := alc.productsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+	saveProduct := func(txCtx context.Context) (err error) {
 		shopID = resRepo.ID
 		productID, err = alc.productsRepository.CreateProduct(txCtx, entity.Product{
 			ProductName:   data.ProductName,
-			Slug:          data.Slug,
+			Slug:          slug,
 			ResellerPrice: data.ResellerPrice,
 			ConsumerPrice: data.ConsumerPrice,
-			Stock:         data.Stock,
 			Description:   data.Description,
 			ShopID:        shopID,
 			CategoryID:    categoryID,
+
+			LowStockThreshold: data.StockMinimum,
 		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errProductSlugTaken
+		}
 		if err != nil {
 			return err
 		}
 
 		fmt.Println(productID)
 
-		for _, photoURL := range photoURLs {
+		for i, photoURL := range photoURLs {
 			_, err = alc.productImagesRepository.CreateProductImage(txCtx, entity.ProductImage{
 				ProductID: productID,
 				PhotoURL:  photoURL,
+				Position:  i,
+				IsPrimary: i == 0,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(variants) == 0 {
+			return alc.inventory.ApplyStockChange(txCtx, StockChange{
+				ProductID: productID,
+				Delta:     data.Stock,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
+			})
+		}
+
+		if err = alc.variantsRepository.ReplaceProductOptions(txCtx, productID, optionsToEntity(options)); err != nil {
+			return err
+		}
+
+		for _, variant := range variants {
+			variantID, err := alc.variantsRepository.CreateProductVariant(txCtx, variantToEntity(productID, variant, variantPhotoURLs))
+			if err != nil {
+				return err
+			}
+
+			err = alc.inventory.ApplyStockChange(txCtx, StockChange{
+				ProductID: productID,
+				VariantID: &variantID,
+				Delta:     variant.Stock,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
 			})
 			if err != nil {
 				return err
@@ -133,10 +261,27 @@ func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string
 		}
 
 		return nil
+	}
+
+	errTransaction := alc.productsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		if err = saveProduct(txCtx); err != nil {
+			return err
+		}
+
+		_, err = recordProductVersion(txCtx, alc.productsRepository, alc.productLogsRepository, productID, actorID)
+		return err
 	})
 	if errTransaction != nil {
-		for _, photoURL := range photoURLs {
-			_ = os.Remove(photoURL)
+		for _, photoURL := range append(photoURLs, variantPhotoURLs...) {
+			_ = removeImage(ctx, alc.files, productUploadDir, photoURL)
+		}
+
+		// Another product of the shop took the slug in the meantime.
+		if errors.Is(errTransaction, errProductSlugTaken) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusConflict,
+				Err:  errProductSlugTaken,
+			}
 		}
 
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
@@ -146,10 +291,19 @@ func (alc *ProductsUseCaseImpl) CreateProduct(ctx context.Context, userID string
 		}
 	}
 
+	syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, productID)
+
 	return productID, nil
 }
 
-func (alc *ProductsUseCaseImpl) GetAllProducts(ctx context.Context, params model.ProductsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+func (alc *ProductsUseCaseImpl) GetAllProducts(ctx context.Context, userID string, params model.ProductsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(params); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
 	var products []model.ProductResp
 
 	limit, offset := func(limit, page int) (int, int) {
@@ -166,16 +320,44 @@ func (alc *ProductsUseCaseImpl) GetAllProducts(ctx context.Context, params model
 		return limit, offset
 	}(params.Limit, params.Page)
 
-	resRepo, errRepo := alc.productsRepository.GetAllProducts(ctx, entity.FilterProducts{
+	// Filtering by a category includes the products of its descendants.
+	var categoryIDs []uint
+	if params.CategoryID > 0 {
+		categories, errRepo := alc.categoriesRepository.GetCategories(ctx)
+		if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategories: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+
+		categoryIDs = categoryDescendantIDs(categories, params.CategoryID)
+		if categoryIDs == nil {
+			categoryIDs = []uint{params.CategoryID}
+		}
+	}
+
+	productIDs, errIndex := alc.searchIndex.SearchProductIDs(ctx, entity.FilterProducts{
 		Limit:       limit,
 		Offset:      offset,
-		ProductName: params.ProductName,
-		CategoryID:  params.CategoryID,
+		SearchQuery: strings.Join(utils.NormalizeSearchQuery(params.ProductName), " "),
+		Sort:        params.Sort,
+		CategoryIDs: categoryIDs,
 		ShopID:      params.ShopID,
 		MaxPrice:    params.MaxPrice,
 		MinPrice:    params.MinPrice,
+		InStockOnly: !params.IncludeOutOfStock,
 	})
+	if errIndex != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at SearchProductIDs: %s", errIndex.Error()), errIndex)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errIndex,
+		}
+	}
 
+	resRepo, errRepo := alc.productsRepository.GetProductsByIDs(ctx, productIDs)
 	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
 			return res, &helper.ErrorStruct{
@@ -191,35 +373,31 @@ func (alc *ProductsUseCaseImpl) GetAllProducts(ctx context.Context, params model
 		}
 	}
 
-	for _, v := range resRepo {
-		var imageResponses []model.ProductImageResp
-		for _, img := range v.Images {
-			imageResponses = append(imageResponses, model.ProductImageResp{
-				ID:        img.ID,
-				ProductID: img.ProductID,
-				ImageURL:  img.PhotoURL,
-			})
+	wishlisted := make(map[uint]bool)
+	if userID != "" {
+		var productIDs []uint
+		for _, v := range resRepo {
+			productIDs = append(productIDs, v.ID)
 		}
 
-		products = append(products, model.ProductResp{
-			ID:            v.ID,
-			ProductName:   v.ProductName,
-			Slug:          v.Slug,
-			ResellerPrice: v.ResellerPrice,
-			ConsumerPrice: v.ConsumerPrice,
-			Stock:         v.Stock,
-			Description:   v.Description,
-			Shop: model.ShopResp{
-				ID:       v.Shop.ID,
-				ShopName: v.Shop.ShopName,
-				PhotoURL: v.Shop.PhotoURL,
-			},
-			Category: model.CategoryResp{
-				ID:           v.Category.ID,
-				CategoryName: v.Category.CategoryName,
-			},
-			Images: imageResponses,
-		})
+		wishlistRepo, errRepo := alc.wishlistsRepository.GetWishlistedProductIDs(ctx, userID, productIDs)
+		if errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetWishlistedProductIDs: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+
+		for _, id := range wishlistRepo {
+			wishlisted[id] = true
+		}
+	}
+
+	for _, v := range resRepo {
+		product := productToResp(alc.files, v)
+		product.IsWishlisted = wishlisted[v.ID]
+		products = append(products, product)
 	}
 
 	res = model.FilteredData{
@@ -233,6 +411,9 @@ func (alc *ProductsUseCaseImpl) GetAllProducts(ctx context.Context, params model
 
 func (alc *ProductsUseCaseImpl) GetProductByID(ctx context.Context, productID string) (res model.ProductResp, err *helper.ErrorStruct) {
 	resRepo, errRepo := alc.productsRepository.GetProductByID(ctx, productID)
+	if errRepo == nil && productModerated(resRepo) {
+		errRepo = gorm.ErrRecordNotFound
+	}
 	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
 			return res, &helper.ErrorStruct{
@@ -248,39 +429,62 @@ func (alc *ProductsUseCaseImpl) GetProductByID(ctx context.Context, productID st
 		}
 	}
 
-	var images []model.ProductImageResp
-	for _, img := range resRepo.Images {
-		images = append(images, model.ProductImageResp{
-			ID:        img.ID,
-			ProductID: img.ProductID,
-			ImageURL:  img.PhotoURL,
-		})
+	res = productToResp(alc.files, resRepo)
+
+	return res, err
+}
+
+func (alc *ProductsUseCaseImpl) GetProductBySlug(ctx context.Context, shopSlug string, slug string) (res model.ProductResp, redirectSlug string, err *helper.ErrorStruct) {
+	resShopRepo, errRepo := alc.shopsRepository.GetShopBySlug(ctx, shopSlug)
+	if errRepo == nil && resShopRepo.SuspendedAt != nil {
+		errRepo = gorm.ErrRecordNotFound
 	}
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, redirectSlug, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
 
-	res = model.ProductResp{
-		ID:            resRepo.ID,
-		ProductName:   resRepo.ProductName,
-		Slug:          resRepo.Slug,
-		ResellerPrice: resRepo.ResellerPrice,
-		ConsumerPrice: resRepo.ConsumerPrice,
-		Stock:         resRepo.Stock,
-		Description:   resRepo.Description,
-		Shop: model.ShopResp{
-			ID:       resRepo.Shop.ID,
-			ShopName: resRepo.Shop.ShopName,
-			PhotoURL: resRepo.Shop.PhotoURL,
-		},
-		Category: model.CategoryResp{
-			ID:           resRepo.Category.ID,
-			CategoryName: resRepo.Category.CategoryName,
-		},
-		Images: images,
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopBySlug: %s", errRepo.Error()), errRepo)
+		return res, redirectSlug, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
 	}
 
-	return res, err
+	resRepo, errRepo := alc.productsRepository.GetProductBySlug(ctx, resShopRepo.ID, slug)
+	if errRepo == nil && productModerated(resRepo) {
+		return res, redirectSlug, &helper.ErrorStruct{
+			Code: fiber.StatusNotFound,
+			Err:  errors.New("produk tidak ditemukan"),
+		}
+	}
+	if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		redirect, errRedirect := alc.slugRedirectsRepository.GetSlugRedirect(ctx, resShopRepo.ID, slug)
+		if errRedirect == nil && redirect.Product.ID != 0 {
+			return res, redirect.Product.Slug, nil
+		}
+
+		return res, redirectSlug, &helper.ErrorStruct{
+			Code: fiber.StatusNotFound,
+			Err:  errors.New("produk tidak ditemukan"),
+		}
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductBySlug: %s", errRepo.Error()), errRepo)
+		return res, redirectSlug, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return productToResp(alc.files, resRepo), redirectSlug, nil
 }
 
-func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID string, productID string, data model.ProductReqUpdate, files []*multipart.FileHeader) (res string, err *helper.ErrorStruct) {
+func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID string, productID string, data model.ProductReqUpdate, files []*multipart.FileHeader, variantFiles []*multipart.FileHeader) (res string, err *helper.ErrorStruct) {
 	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		log.Println(errValidate)
 		return res, &helper.ErrorStruct{
@@ -289,6 +493,14 @@ func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID st
 		}
 	}
 
+	options, variants, errParse := parseProductVariants(data.Options, data.Variants, len(variantFiles))
+	if errParse != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errParse,
+		}
+	}
+
 	resProductRepo, errRepo := alc.productsRepository.GetProductByID(ctx, productID)
 	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
@@ -356,10 +568,38 @@ func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID st
 		}
 	}
 
-	uploadDir := "files/products"
+	var slug string
+	slugSource := data.Slug
+	if slugSource == "" && data.ProductName != "" && utils.GenerateSlug(data.ProductName) != utils.GenerateSlug(resProductRepo.ProductName) {
+		slugSource = data.ProductName
+	}
+
+	if slugSource != "" {
+		newSlug, errSlug := alc.uniqueProductSlug(ctx, resProductRepo.ShopID, slugSource, resProductRepo.ID)
+		if errSlug != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetTakenSlugs: %s", errSlug.Error()), errSlug)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errSlug,
+			}
+		}
+
+		if newSlug != resProductRepo.Slug {
+			slug = newSlug
+		}
+	}
+
+	if len(files) > alc.photoLimits.Max {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  fmt.Errorf("jumlah foto produk maksimal %d", alc.photoLimits.Max),
+		}
+	}
+
+	uploadDir := productUploadDir
 	var photoURLs []string
 	for _, fileHeader := range files {
-		photoURL, err := utils.SaveFileToDisk(fileHeader, uploadDir)
+		photoURL, err := saveImage(ctx, alc.files, uploadDir, fileHeader, alc.photoLimits.MaxSize)
 		if err != nil {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusBadRequest,
@@ -369,23 +609,88 @@ func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID st
 		photoURLs = append(photoURLs, photoURL)
 	}
 
-	errTransaction := alc.productsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+	var variantPhotoURLs []string
+	for _, fileHeader := range variantFiles {
+		photoURL, err := saveImage(ctx, alc.files, uploadDir, fileHeader, alc.photoLimits.MaxSize)
+		if err != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  err,
+			}
+		}
+		variantPhotoURLs = append(variantPhotoURLs, photoURL)
+	}
+
+	existingVariants := make(map[uint]bool)
+	for _, v := range resProductRepo.Variants {
+		existingVariants[v.ID] = true
+	}
+
+	for _, v := range variants {
+		if v.ID != 0 && !existingVariants[v.ID] {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  fmt.Errorf("varian %d tidak ditemukan", v.ID),
+			}
+		}
+	}
+
+	actorID := stockActorID(userID)
+
+	saveProduct := func(txCtx context.Context) (err error) {
 		err = alc.productsRepository.UpdateProductByID(txCtx, productID, entity.Product{
 			ProductName:   data.ProductName,
-			Slug:          data.Slug,
+			Slug:          slug,
 			CategoryID:    categoryID,
 			ResellerPrice: data.ResellerPrice,
 			ConsumerPrice: data.ConsumerPrice,
-			Stock:         data.Stock,
 			Description:   data.Description,
 		})
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errProductSlugTaken
+		}
 		if err != nil {
 			return err
 		}
 
+		if data.StockMinimum != nil {
+			err = alc.productsRepository.UpdateLowStockThreshold(txCtx, resProductRepo.ID, *data.StockMinimum)
+			if err != nil {
+				return err
+			}
+		}
+
+		if slug != "" {
+			if resProductRepo.Slug != "" {
+				_, err = alc.slugRedirectsRepository.CreateSlugRedirect(txCtx, entity.ProductSlugRedirect{
+					ShopID:    resProductRepo.ShopID,
+					Slug:      resProductRepo.Slug,
+					ProductID: resProductRepo.ID,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if err = alc.slugRedirectsRepository.DeleteSlugRedirect(txCtx, resProductRepo.ID, slug); err != nil {
+				return err
+			}
+		}
+
+		// Uploaded photos replace the existing ones position by position,
+		// extra photos are appended after them.
 		for i, photoURL := range photoURLs {
-			if i >= len(photoURLs) {
-				break
+			if i >= len(resProductRepo.Images) {
+				_, err = alc.productImagesRepository.CreateProductImage(txCtx, entity.ProductImage{
+					ProductID: resProductRepo.ID,
+					PhotoURL:  photoURL,
+					Position:  i,
+					IsPrimary: i == 0,
+				})
+				if err != nil {
+					return err
+				}
+				continue
 			}
 
 			imageID := fmt.Sprintf("%d", resProductRepo.Images[i].ID)
@@ -397,11 +702,104 @@ func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID st
 			}
 		}
 
-		return nil
+		// The stock of a product with variants is the sum of its variants,
+		// so a plain stok field only applies to products without them.
+		if len(variants) == 0 {
+			if data.Stock == nil || len(resProductRepo.Variants) > 0 {
+				return nil
+			}
+
+			return alc.inventory.SetStock(txCtx, StockChange{
+				ProductID: resProductRepo.ID,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
+			}, *data.Stock)
+		}
+
+		if len(resProductRepo.Variants) == 0 {
+			err = alc.inventory.SetStock(txCtx, StockChange{
+				ProductID: resProductRepo.ID,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
+			}, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = alc.variantsRepository.ReplaceProductOptions(txCtx, resProductRepo.ID, optionsToEntity(options)); err != nil {
+			return err
+		}
+
+		keep := make(map[uint]bool)
+		for _, variant := range variants {
+			variantID := variant.ID
+			if variantID == 0 {
+				variantID, err = alc.variantsRepository.CreateProductVariant(txCtx, variantToEntity(resProductRepo.ID, variant, variantPhotoURLs))
+				if err != nil {
+					return err
+				}
+			} else if err = alc.variantsRepository.UpdateProductVariant(txCtx, variantID, variantToEntity(resProductRepo.ID, variant, variantPhotoURLs)); err != nil {
+				return err
+			}
+
+			err = alc.inventory.SetStock(txCtx, StockChange{
+				ProductID: resProductRepo.ID,
+				VariantID: &variantID,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
+			}, variant.Stock)
+			if err != nil {
+				return err
+			}
+			keep[variantID] = true
+		}
+
+		// Removed variants take their stock with them.
+		var keepIDs []uint
+		for id := range keep {
+			keepIDs = append(keepIDs, id)
+		}
+
+		for _, variant := range resProductRepo.Variants {
+			if keep[variant.ID] {
+				continue
+			}
+
+			variantID := variant.ID
+			err = alc.inventory.SetStock(txCtx, StockChange{
+				ProductID: resProductRepo.ID,
+				VariantID: &variantID,
+				Reason:    entity.StockReasonAdjustment,
+				ActorID:   actorID,
+			}, 0)
+			if err != nil {
+				return err
+			}
+		}
+
+		return alc.variantsRepository.DeleteVariantsExcept(txCtx, resProductRepo.ID, keepIDs)
+	}
+
+	errTransaction := alc.productsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		if err = saveProduct(txCtx); err != nil {
+			return err
+		}
+
+		_, err = recordProductVersion(txCtx, alc.productsRepository, alc.productLogsRepository, resProductRepo.ID, actorID)
+		return err
 	})
 	if errTransaction != nil {
-		for _, photoURL := range photoURLs {
-			_ = os.Remove(photoURL)
+		for _, photoURL := range append(photoURLs, variantPhotoURLs...) {
+			_ = removeImage(ctx, alc.files, productUploadDir, photoURL)
+		}
+
+		// Another product of the shop took the slug in the meantime.
+		if errors.Is(errTransaction, errProductSlugTaken) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusConflict,
+				Err:  errProductSlugTaken,
+			}
 		}
 
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
@@ -411,6 +809,14 @@ func (alc *ProductsUseCaseImpl) UpdateProductByID(ctx context.Context, userID st
 		}
 	}
 
+	for i := range photoURLs {
+		if i < len(resProductRepo.Images) {
+			_ = removeImage(ctx, alc.files, productUploadDir, resProductRepo.Images[i].PhotoURL)
+		}
+	}
+
+	syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, resProductRepo.ID)
+
 	return "updated", nil
 }
 
@@ -447,13 +853,407 @@ func (alc *ProductsUseCaseImpl) DeleteProductByID(ctx context.Context, userID st
 		}
 	}
 
-	if errRepo := alc.productsRepository.DeleteProductByID(ctx, productID); errRepo != nil {
-		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteProductByID: %s", errRepo.Error()), errRepo)
+	errTransaction := alc.productsRepository.WithinTransaction(ctx, func(txCtx context.Context) (err error) {
+		if err = alc.productsRepository.DeleteProductByID(txCtx, productID); err != nil {
+			return err
+		}
+
+		return alc.wishlistsRepository.DeleteWishlistsByProductID(txCtx, productID)
+	})
+	if errTransaction != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
-			Err:  errRepo,
+			Err:  errTransaction,
 		}
 	}
 
+	productIDNum, _ := utils.ConvertStringToUint(productID)
+	syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, productIDNum)
+
 	return "deleted", nil
 }
+
+func (alc *ProductsUseCaseImpl) ReindexProducts(ctx context.Context) (res int, err *helper.ErrorStruct) {
+	if errIndex := alc.searchIndex.ResetIndex(ctx); errIndex != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ResetIndex: %s", errIndex.Error()), errIndex)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errIndex,
+		}
+	}
+
+	const batchSize = 500
+	var lastID uint
+	for {
+		resRepo, errRepo := alc.productsRepository.GetProductsAfterID(ctx, lastID, batchSize)
+		if errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductsAfterID: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusInternalServerError,
+				Err:  errRepo,
+			}
+		}
+
+		if len(resRepo) == 0 {
+			break
+		}
+
+		var docs []entity.ProductSearchDocument
+		for _, product := range resRepo {
+			if productModerated(product) {
+				continue
+			}
+
+			doc, errDoc := productToSearchDocument(ctx, alc.productsRepository, product)
+			if errDoc != nil {
+				return res, &helper.ErrorStruct{
+					Code: fiber.StatusInternalServerError,
+					Err:  errDoc,
+				}
+			}
+			docs = append(docs, doc)
+		}
+
+		if len(docs) > 0 {
+			if errIndex := alc.searchIndex.IndexProducts(ctx, docs); errIndex != nil {
+				helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at IndexProducts: %s", errIndex.Error()), errIndex)
+				return res, &helper.ErrorStruct{
+					Code: fiber.StatusInternalServerError,
+					Err:  errIndex,
+				}
+			}
+		}
+
+		res += len(docs)
+		lastID = resRepo[len(resRepo)-1].ID
+	}
+
+	return res, nil
+}
+
+// uniqueProductSlug builds a slug from text that no other product of the shop
+// uses, falling back to "produk" when text has no usable characters.
+func (alc *ProductsUseCaseImpl) uniqueProductSlug(ctx context.Context, shopID uint, text string, productID uint) (string, error) {
+	base := utils.GenerateSlug(text)
+	if base == "" {
+		base = "produk"
+	}
+
+	taken, err := alc.productsRepository.GetTakenSlugs(ctx, shopID, base, productID)
+	if err != nil {
+		return "", err
+	}
+
+	return utils.UniqueSlug(base, taken), nil
+}
+
+func productToResp(files storage.Storage, v entity.Product) model.ProductResp {
+	var images []model.ProductImageResp
+	for _, img := range v.Images {
+		images = append(images, model.ProductImageResp{
+			ID:         img.ID,
+			ProductID:  img.ProductID,
+			ImageURL:   fileURL(files, productUploadDir, img.PhotoURL),
+			Renditions: imageRenditionsResp(files, productUploadDir, img.PhotoURL),
+			Position:   img.Position,
+			IsPrimary:  img.IsPrimary,
+		})
+	}
+
+	var options []model.ProductOptionResp
+	for _, opt := range v.Options {
+		options = append(options, model.ProductOptionResp{
+			ID:         opt.ID,
+			OptionName: opt.OptionName,
+			Values:     opt.Values,
+		})
+	}
+
+	var variants []model.ProductVariantResp
+	for _, variant := range v.Variants {
+		variants = append(variants, model.ProductVariantResp{
+			ID:            variant.ID,
+			SKU:           variant.SKU,
+			Options:       variant.Options,
+			ResellerPrice: variant.ResellerPrice,
+			ConsumerPrice: variant.ConsumerPrice,
+			Stock:         variant.Stock,
+			ImageURL:      fileURL(files, productUploadDir, variant.PhotoURL),
+		})
+	}
+
+	return model.ProductResp{
+		ID:            v.ID,
+		ProductName:   v.ProductName,
+		Slug:          v.Slug,
+		ResellerPrice: v.ResellerPrice,
+		ConsumerPrice: v.ConsumerPrice,
+		Stock:         v.Stock,
+		StockMinimum:  v.LowStockThreshold,
+		Description:   v.Description,
+		Shop: model.ShopResp{
+			ID:       v.Shop.ID,
+			ShopName: v.Shop.ShopName,
+			Slug:     v.Shop.Slug,
+			PhotoURL: fileURL(files, shopUploadDir, v.Shop.PhotoURL),
+		},
+		Category: model.CategoryResp{
+			ID:           v.Category.ID,
+			CategoryName: v.Category.CategoryName,
+		},
+		Images:   images,
+		Options:  options,
+		Variants: variants,
+	}
+}
+
+// parseProductVariants decodes the opsi and varian form fields and checks that
+// every variant picks exactly one declared value for each option.
+func parseProductVariants(optionsRaw, variantsRaw string, photoCount int) (options []model.ProductOptionReq, variants []model.ProductVariantReq, err error) {
+	if optionsRaw == "" && variantsRaw == "" {
+		return nil, nil, nil
+	}
+
+	if optionsRaw == "" || variantsRaw == "" {
+		return nil, nil, errors.New("opsi dan varian harus diisi bersamaan")
+	}
+
+	if err := json.Unmarshal([]byte(optionsRaw), &options); err != nil {
+		return nil, nil, errors.New("format opsi tidak valid")
+	}
+
+	if err := json.Unmarshal([]byte(variantsRaw), &variants); err != nil {
+		return nil, nil, errors.New("format varian tidak valid")
+	}
+
+	if len(options) == 0 || len(variants) == 0 {
+		return nil, nil, errors.New("opsi dan varian tidak boleh kosong")
+	}
+
+	allowed := make(map[string]map[string]bool)
+	for _, opt := range options {
+		if err := helper.Validate.Struct(opt); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := allowed[opt.OptionName]; ok {
+			return nil, nil, fmt.Errorf("opsi %s duplikat", opt.OptionName)
+		}
+
+		allowed[opt.OptionName] = make(map[string]bool)
+		for _, value := range opt.Values {
+			allowed[opt.OptionName][value] = true
+		}
+	}
+
+	skus := make(map[string]bool)
+	combinations := make(map[string]bool)
+	for _, variant := range variants {
+		if err := helper.Validate.Struct(variant); err != nil {
+			return nil, nil, err
+		}
+
+		if len(variant.Options) != len(allowed) {
+			return nil, nil, fmt.Errorf("varian %s harus memilih semua opsi", variant.SKU)
+		}
+
+		var keys []string
+		for name, value := range variant.Options {
+			if !allowed[name][value] {
+				return nil, nil, fmt.Errorf("opsi %s: %s pada varian %s tidak valid", name, value, variant.SKU)
+			}
+			keys = append(keys, name+"="+value)
+		}
+		sort.Strings(keys)
+
+		combination := strings.Join(keys, "|")
+		if combinations[combination] {
+			return nil, nil, fmt.Errorf("kombinasi opsi varian %s duplikat", variant.SKU)
+		}
+		combinations[combination] = true
+
+		if skus[variant.SKU] {
+			return nil, nil, fmt.Errorf("sku %s duplikat", variant.SKU)
+		}
+		skus[variant.SKU] = true
+
+		if variant.PhotoIndex != nil && (*variant.PhotoIndex < 0 || *variant.PhotoIndex >= photoCount) {
+			return nil, nil, fmt.Errorf("index_foto varian %s tidak valid", variant.SKU)
+		}
+	}
+
+	return options, variants, nil
+}
+
+func optionsToEntity(options []model.ProductOptionReq) (res []entity.ProductOption) {
+	for _, opt := range options {
+		res = append(res, entity.ProductOption{
+			OptionName: opt.OptionName,
+			Values:     opt.Values,
+		})
+	}
+	return res
+}
+
+func variantToEntity(productID uint, variant model.ProductVariantReq, photoURLs []string) entity.ProductVariant {
+	res := entity.ProductVariant{
+		ProductID:     productID,
+		SKU:           variant.SKU,
+		Options:       variant.Options,
+		ResellerPrice: variant.ResellerPrice,
+		ConsumerPrice: variant.ConsumerPrice,
+	}
+
+	if variant.PhotoIndex != nil {
+		res.PhotoURL = photoURLs[*variant.PhotoIndex]
+	}
+
+	return res
+}
+
+func (alc *ProductsUseCaseImpl) GetStockHistory(ctx context.Context, userID string, productID string, params model.StockMovementsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	limit, offset := func(limit, page int) (int, int) {
+		if limit < 1 {
+			limit = 10
+		}
+
+		var offset int
+		if page < 1 {
+			offset = 0
+		} else {
+			offset = (page - 1) * limit
+		}
+		return limit, offset
+	}(params.Limit, params.Page)
+
+	resRepo, errRepo := alc.inventory.GetStockMovements(ctx, entity.FilterStockMovements{
+		Limit:     limit,
+		Offset:    offset,
+		ProductID: product.ID,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetStockMovements: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	movements := make([]model.StockMovementResp, 0, len(resRepo))
+	for _, v := range resRepo {
+		movements = append(movements, model.StockMovementResp{
+			ID:                v.ID,
+			VariantID:         v.VariantID,
+			Delta:             v.Delta,
+			StockAfter:        v.StockAfter,
+			VariantStockAfter: v.VariantStockAfter,
+			Reason:            v.Reason,
+			TrxID:             v.TrxID,
+			ActorID:           v.ActorID,
+			CreatedAt:         v.CreatedAt,
+		})
+	}
+
+	res = model.FilteredData{
+		Data:  movements,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+
+	return res, nil
+}
+
+// GetProductHistory lists the versions of a product, newest first, each with
+// the fields changed since the version before it.
+func (alc *ProductsUseCaseImpl) GetProductHistory(ctx context.Context, userID string, productID string, params model.ProductHistoryFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	product, err := alc.getOwnedProduct(ctx, userID, productID)
+	if err != nil {
+		return res, err
+	}
+
+	limit, offset := func(limit, page int) (int, int) {
+		if limit < 1 {
+			limit = 10
+		}
+
+		var offset int
+		if page < 1 {
+			offset = 0
+		} else {
+			offset = (page - 1) * limit
+		}
+		return limit, offset
+	}(params.Limit, params.Page)
+
+	// One extra version is read so the oldest version of the page can be
+	// compared with the one before it.
+	resRepo, errRepo := alc.productLogsRepository.GetProductLogs(ctx, entity.FilterProductLogs{
+		Limit:     limit + 1,
+		Offset:    offset,
+		ProductID: product.ID,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductLogs: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	versions := make([]model.ProductVersionResp, 0, limit)
+	for i, v := range resRepo {
+		if i == limit {
+			break
+		}
+
+		var previous *entity.ProductLog
+		if i+1 < len(resRepo) {
+			previous = &resRepo[i+1]
+		}
+
+		var variants []model.ProductLogVariant
+		for _, variant := range v.Variants {
+			variants = append(variants, productLogVariantToResp(variant))
+		}
+
+		var editor *model.ProductEditorResp
+		if v.Editor != nil {
+			editor = &model.ProductEditorResp{
+				ID:   v.Editor.ID,
+				Name: v.Editor.Name,
+			}
+		}
+
+		versions = append(versions, model.ProductVersionResp{
+			ID:            v.ID,
+			Version:       v.Version,
+			ProductName:   v.ProductName,
+			Slug:          v.Slug,
+			ResellerPrice: v.ResellerPrice,
+			ConsumerPrice: v.ConsumerPrice,
+			Description:   v.Description,
+			Category: model.CategoryResp{
+				ID:           v.Category.ID,
+				CategoryName: v.Category.CategoryName,
+			},
+			Variants:  variants,
+			Editor:    editor,
+			Changes:   productVersionChanges(previous, v),
+			CreatedAt: v.CreatedAt,
+		})
+	}
+
+	res = model.FilteredData{
+		Data:  versions,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+
+	return res, nil
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RolesUseCase lets admins assign roles. Only superadmins may grant the
+// superadmin role or change the roles of a superadmin.
+type RolesUseCase interface {
+	GetRoles(ctx context.Context) (res []model.RoleResp, err *helper.ErrorStruct)
+	GetUserRoles(ctx context.Context, userID string) (res model.UserRolesResp, err *helper.ErrorStruct)
+	UpdateUserRoles(ctx context.Context, actor helper.AuthUser, userID string, data model.UserRolesReqUpdate) (res model.UserRolesResp, err *helper.ErrorStruct)
+	GrantRoleByPhoneNumber(ctx context.Context, phoneNumber string, role string) (err *helper.ErrorStruct)
+}
+
+type RolesUseCaseImpl struct {
+	rolesRepository repository.RolesRepository
+	usersRepository repository.UsersRepository
+}
+
+func NewRolesUseCase(
+	rolesRepository repository.RolesRepository,
+	usersRepository repository.UsersRepository,
+) RolesUseCase {
+	return &RolesUseCaseImpl{
+		rolesRepository: rolesRepository,
+		usersRepository: usersRepository,
+	}
+}
+
+func (alc *RolesUseCaseImpl) GetRoles(ctx context.Context) (res []model.RoleResp, err *helper.ErrorStruct) {
+	roles, errRepo := alc.rolesRepository.GetRoles(ctx)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetRoles: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	res = []model.RoleResp{}
+	for _, role := range roles {
+		permissions := []string{}
+		for _, permission := range role.Permissions {
+			permissions = append(permissions, permission.Name)
+		}
+
+		res = append(res, model.RoleResp{
+			ID:          role.ID,
+			Name:        role.Name,
+			Permissions: permissions,
+		})
+	}
+
+	return res, nil
+}
+
+func (alc *RolesUseCaseImpl) GetUserRoles(ctx context.Context, userID string) (res model.UserRolesResp, err *helper.ErrorStruct) {
+	user, err := alc.getUser(ctx, userID)
+	if err != nil {
+		return res, err
+	}
+
+	roles, errRepo := alc.rolesRepository.GetUserRoleNames(ctx, userID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserRoleNames: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	return model.UserRolesResp{
+		UserID: user.ID,
+		Roles:  roles,
+	}, nil
+}
+
+// UpdateUserRoles replaces the roles of a user. The new permissions apply
+// from the next request, tokens do not carry them.
+func (alc *RolesUseCaseImpl) UpdateUserRoles(ctx context.Context, actor helper.AuthUser, userID string, data model.UserRolesReqUpdate) (res model.UserRolesResp, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	if actor.ID == userID {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("tidak dapat mengubah peran sendiri"),
+		}
+	}
+
+	current, err := alc.GetUserRoles(ctx, userID)
+	if err != nil {
+		return res, err
+	}
+
+	roles, errRepo := alc.rolesRepository.GetRolesByNames(ctx, data.Roles)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetRolesByNames: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	found := map[string]bool{}
+	for _, role := range roles {
+		found[role.Name] = true
+	}
+	for _, name := range data.Roles {
+		if !found[name] {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  fmt.Errorf("peran %s tidak ditemukan", name),
+			}
+		}
+	}
+
+	touchesSuperadmin := found[entity.RoleSuperadmin]
+	for _, name := range current.Roles {
+		if name == entity.RoleSuperadmin {
+			touchesSuperadmin = true
+		}
+	}
+	if touchesSuperadmin && !actor.HasRole(entity.RoleSuperadmin) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusForbidden,
+			Err:  errors.New("hanya superadmin yang dapat mengatur peran superadmin"),
+		}
+	}
+
+	if errRepo := alc.rolesRepository.ReplaceUserRoles(ctx, current.UserID, roles); errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at ReplaceUserRoles: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengubah peran user"),
+		}
+	}
+
+	helper.Logger(helper.LoggerLevelInfo, fmt.Sprintf("User %s set the roles of user %s to %v", actor.ID, userID, data.Roles), nil)
+	return alc.GetUserRoles(ctx, userID)
+}
+
+// GrantRoleByPhoneNumber adds role to a user, for bootstrapping the first
+// superadmin from the command line.
+func (alc *RolesUseCaseImpl) GrantRoleByPhoneNumber(ctx context.Context, phoneNumber string, role string) (err *helper.ErrorStruct) {
+	user, errRepo := alc.usersRepository.GetUserByPhoneNumber(ctx, phoneNumber)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		return &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	roles, errRepo := alc.rolesRepository.GetRolesByNames(ctx, []string{role})
+	if errRepo != nil {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	if len(roles) == 0 {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  fmt.Errorf("peran %s tidak ditemukan", role),
+		}
+	}
+
+	if errRepo := alc.rolesRepository.AddUserRoles(ctx, user.ID, roles); errRepo != nil {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	return nil
+}
+
+func (alc *RolesUseCaseImpl) getUser(ctx context.Context, userID string) (res entity.User, err *helper.ErrorStruct) {
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"backend-evermos/internal/utils"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var errInvalidFeedCursor = errors.New("cursor tidak valid")
+
+type ShopFollowsUseCase interface {
+	FollowShop(ctx context.Context, userID string, shopID string) (res uint, err *helper.ErrorStruct)
+	UnfollowShop(ctx context.Context, userID string, shopID string) (res string, err *helper.ErrorStruct)
+	GetMyFollowing(ctx context.Context, userID string, params model.ShopFollowsFilter) (res model.FilteredData, err *helper.ErrorStruct)
+	GetMyFeed(ctx context.Context, userID string, params model.FeedFilter) (res model.FeedResp, err *helper.ErrorStruct)
+}
+
+type ShopFollowsUseCaseImpl struct {
+	shopFollowsRepository repository.ShopFollowsRepository
+	shopsRepository       repository.ShopsRepository
+	files                 storage.Storage
+}
+
+func NewShopFollowsUseCase(
+	shopFollowsRepository repository.ShopFollowsRepository,
+	shopsRepository repository.ShopsRepository,
+	files storage.Storage,
+) ShopFollowsUseCase {
+	return &ShopFollowsUseCaseImpl{
+		shopFollowsRepository: shopFollowsRepository,
+		shopsRepository:       shopsRepository,
+		files:                 files,
+	}
+}
+
+func (alc *ShopFollowsUseCaseImpl) FollowShop(ctx context.Context, userID string, shopID string) (res uint, err *helper.ErrorStruct) {
+	userIDNum, errConv := utils.ConvertStringToUint(userID)
+	if errConv != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errConv,
+		}
+	}
+
+	resShopRepo, errRepo := alc.shopsRepository.GetShopByID(ctx, shopID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	if resShopRepo.UserID == userIDNum {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("tidak dapat mengikuti toko sendiri"),
+		}
+	}
+
+	errRepo = alc.shopFollowsRepository.VerifyShopFollowAvailability(ctx, userID, shopID)
+	if errRepo == nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("toko sudah diikuti"),
+		}
+	}
+
+	if !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at VerifyShopFollowAvailability: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	resRepo, errRepo := alc.shopFollowsRepository.CreateShopFollow(ctx, entity.ShopFollow{
+		UserID: userIDNum,
+		ShopID: resShopRepo.ID,
+	})
+	if errors.Is(errRepo, gorm.ErrDuplicatedKey) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("toko sudah diikuti"),
+		}
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CreateShopFollow: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal mengikuti toko"),
+		}
+	}
+
+	return resRepo, nil
+}
+
+func (alc *ShopFollowsUseCaseImpl) UnfollowShop(ctx context.Context, userID string, shopID string) (res string, err *helper.ErrorStruct) {
+	if errRepo := alc.shopFollowsRepository.DeleteShopFollow(ctx, userID, shopID); errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko belum diikuti"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteShopFollow: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return "deleted", nil
+}
+
+func (alc *ShopFollowsUseCaseImpl) GetMyFollowing(ctx context.Context, userID string, params model.ShopFollowsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	var follows []model.ShopFollowResp
+
+	limit, offset := func(limit, page int) (int, int) {
+		if limit < 1 {
+			limit = 10
+		}
+
+		var offset int
+		if page < 1 {
+			offset = 0
+		} else {
+			offset = (page - 1) * limit
+		}
+		return limit, offset
+	}(params.Limit, params.Page)
+
+	resRepo, errRepo := alc.shopFollowsRepository.GetShopFollowsByUserID(ctx, userID, entity.FilterShopFollows{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopFollowsByUserID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	shopIDs := make([]uint, 0, len(resRepo))
+	for _, v := range resRepo {
+		shopIDs = append(shopIDs, v.ShopID)
+	}
+
+	followers, errRepo := alc.shopsRepository.CountShopFollowers(ctx, shopIDs)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CountShopFollowers: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	for _, v := range resRepo {
+		shop := shopToResp(alc.files, v.Shop)
+		followerCount := followers[v.ShopID]
+		shop.FollowerCount = &followerCount
+
+		follows = append(follows, model.ShopFollowResp{
+			ID:         v.ID,
+			Shop:       shop,
+			FollowedAt: v.CreatedAt,
+		})
+	}
+
+	res = model.FilteredData{
+		Data:  follows,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+
+	return res, nil
+}
+
+// GetMyFeed lists the products of the followed shops by the time they were
+// created or restocked, newest first.
+func (alc *ShopFollowsUseCaseImpl) GetMyFeed(ctx context.Context, userID string, params model.FeedFilter) (res model.FeedResp, err *helper.ErrorStruct) {
+	limit := params.Limit
+	if limit < 1 {
+		limit = 10
+	}
+
+	filter := entity.FilterFeed{
+		// One more than asked tells whether there is a next page.
+		Limit: limit + 1,
+	}
+
+	if params.Cursor != "" {
+		before, beforeID, errCursor := decodeFeedCursor(params.Cursor)
+		if errCursor != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errCursor,
+			}
+		}
+		filter.BeforeTime, filter.BeforeID = before, beforeID
+	}
+
+	resRepo, errRepo := alc.shopFollowsRepository.GetFeedProducts(ctx, userID, filter)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetFeedProducts: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	var nextCursor string
+	if len(resRepo) > limit {
+		resRepo = resRepo[:limit]
+		last := resRepo[len(resRepo)-1]
+		nextCursor = encodeFeedCursor(feedItemTime(last), last.ID)
+	}
+
+	items := make([]model.FeedItemResp, 0, len(resRepo))
+	for _, v := range resRepo {
+		itemType := model.FeedItemNew
+		if v.RestockedAt != nil {
+			itemType = model.FeedItemRestocked
+		}
+
+		items = append(items, model.FeedItemResp{
+			Type:    itemType,
+			Time:    feedItemTime(v),
+			Product: productToResp(alc.files, v),
+		})
+	}
+
+	res = model.FeedResp{
+		Data:       items,
+		NextCursor: nextCursor,
+		Limit:      limit,
+	}
+
+	return res, nil
+}
+
+func feedItemTime(product entity.Product) time.Time {
+	if product.RestockedAt != nil {
+		return *product.RestockedAt
+	}
+
+	return product.CreatedAt
+}
+
+// encodeFeedCursor points right after the feed item at t with the given
+// product id. The cursor is opaque to clients.
+func encodeFeedCursor(t time.Time, productID uint) string {
+	raw := fmt.Sprintf("%d:%d", t.UnixNano(), productID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeFeedCursor(cursor string) (t time.Time, productID uint, err error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return t, 0, errInvalidFeedCursor
+	}
+
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return t, 0, errInvalidFeedCursor
+	}
+
+	unixNano, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return t, 0, errInvalidFeedCursor
+	}
+
+	productID, err = utils.ConvertStringToUint(id)
+	if err != nil {
+		return t, 0, errInvalidFeedCursor
+	}
+
+	return time.Unix(0, unixNano), productID, nil
+}
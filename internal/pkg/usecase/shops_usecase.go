@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/repository"
@@ -11,7 +12,8 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
-	"os"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -19,18 +21,47 @@ import (
 
 type ShopsUseCase interface {
 	GetMyShop(ctx context.Context, userID string) (res model.MyShopResp, err *helper.ErrorStruct)
-	UpdateShopByID(ctx context.Context, shopID string, userID string, data model.ShopReqUpdate, fileHeader *multipart.FileHeader) (res string, err *helper.ErrorStruct)
-	GetShopByID(ctx context.Context, shopID string) (res model.ShopResp, err *helper.ErrorStruct)
+	UpdateShopByID(ctx context.Context, shopID string, userID string, data model.ShopReqUpdate, photo *multipart.FileHeader, banner *multipart.FileHeader) (res string, err *helper.ErrorStruct)
+	GetShopByID(ctx context.Context, shopID string) (res model.ShopProfileResp, err *helper.ErrorStruct)
+	GetShopStorefront(ctx context.Context, shopID string) (res model.ShopStorefrontResp, err *helper.ErrorStruct)
+	GetShopByHandle(ctx context.Context, handle string) (res model.ShopProfileResp, err *helper.ErrorStruct)
+	CheckShopHandle(ctx context.Context, handle string) (res model.ShopHandleAvailabilityResp, err *helper.ErrorStruct)
 	GetAllShops(ctx context.Context, params model.ShopsFilter) (res model.FilteredData, err *helper.ErrorStruct)
 }
 
+// storefrontFeaturedProducts is how many best selling products a
+// storefront shows.
+const storefrontFeaturedProducts = 8
+
+var errShopHandleTaken = errors.New("handle sudah digunakan")
+
 type ShopsUseCaseImpl struct {
-	shopsRepository repository.ShopsRepository
+	shopsRepository      repository.ShopsRepository
+	productsRepository   repository.ProductsRepository
+	categoriesRepository repository.CategoriesRepository
+	provcityRepository   repository.ProvcityRepository
+	maxPhotoSize         int64
+	renameInterval       time.Duration
+	files                storage.Storage
 }
 
-func NewShopsUseCase(shopsRepository repository.ShopsRepository) ShopsUseCase {
+func NewShopsUseCase(
+	shopsRepository repository.ShopsRepository,
+	productsRepository repository.ProductsRepository,
+	categoriesRepository repository.CategoriesRepository,
+	provcityRepository repository.ProvcityRepository,
+	maxPhotoSize int64,
+	renameInterval time.Duration,
+	files storage.Storage,
+) ShopsUseCase {
 	return &ShopsUseCaseImpl{
-		shopsRepository: shopsRepository,
+		shopsRepository:      shopsRepository,
+		productsRepository:   productsRepository,
+		categoriesRepository: categoriesRepository,
+		provcityRepository:   provcityRepository,
+		maxPhotoSize:         maxPhotoSize,
+		renameInterval:       renameInterval,
+		files:                files,
 	}
 }
 
@@ -51,17 +82,25 @@ func (alc *ShopsUseCaseImpl) GetMyShop(ctx context.Context, userID string) (res
 		}
 	}
 
+	profile, err := alc.shopProfileResp(ctx, resRepo)
+	if err != nil {
+		return res, err
+	}
+
 	res = model.MyShopResp{
-		ID:       resRepo.ID,
-		ShopName: resRepo.ShopName,
-		PhotoURL: resRepo.PhotoURL,
-		UserID:   resRepo.UserID,
+		ShopProfileResp: profile,
+		UserID:          resRepo.UserID,
 	}
 
 	return res, nil
 }
 
-func (alc *ShopsUseCaseImpl) UpdateShopByID(ctx context.Context, shopID string, userID string, data model.ShopReqUpdate, fileHeader *multipart.FileHeader) (res string, err *helper.ErrorStruct) {
+// UpdateShopByID updates the profile of a shop. photo and banner are
+// optional, the stored image is only replaced when a new one is uploaded.
+func (alc *ShopsUseCaseImpl) UpdateShopByID(ctx context.Context, shopID string, userID string, data model.ShopReqUpdate, photo *multipart.FileHeader, banner *multipart.FileHeader) (res string, err *helper.ErrorStruct) {
+	data.ShopName = strings.TrimSpace(data.ShopName)
+	data.Handle = strings.ToLower(strings.TrimSpace(data.Handle))
+
 	if errValidate := helper.Validate.Struct(data); errValidate != nil {
 		log.Println(errValidate)
 		return res, &helper.ErrorStruct{
@@ -100,21 +139,80 @@ func (alc *ShopsUseCaseImpl) UpdateShopByID(ctx context.Context, shopID string,
 		}
 	}
 
-	uploadDir := "files/shops"
-	photoURL, errRepo := utils.SaveFileToDisk(fileHeader, uploadDir)
-	if err != nil {
+	resShopRepo, errRepo := alc.shopsRepository.GetShopByID(ctx, shopID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByID: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
+	var renamedAt *time.Time
+	if (data.ShopName != "" && data.ShopName != resShopRepo.ShopName) || (data.Handle != "" && data.Handle != resShopRepo.Slug) {
+		if err := alc.validateRename(ctx, resShopRepo, data); err != nil {
+			return res, err
+		}
+
+		now := time.Now()
+		renamedAt = &now
+	}
+
+	if data.CityID != "" {
+		if _, errRepo := alc.provcityRepository.GetCityByID(data.CityID); errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCityByID: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("kota tidak ditemukan"),
+			}
+		}
+	}
+
+	var photoURL, bannerURL string
+	if photo != nil {
+		photoURL, errRepo = saveImage(ctx, alc.files, shopUploadDir, photo, alc.maxPhotoSize)
+		if errRepo != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+	}
+
+	if banner != nil {
+		bannerURL, errRepo = saveImage(ctx, alc.files, shopUploadDir, banner, alc.maxPhotoSize)
+		if errRepo != nil {
+			_ = removeImage(ctx, alc.files, shopUploadDir, photoURL)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+	}
+
 	errRepo = alc.shopsRepository.UpdateShopByID(ctx, shopID, entity.Shop{
-		ShopName: data.ShopName,
-		PhotoURL: photoURL,
+		ShopName:     data.ShopName,
+		Slug:         data.Handle,
+		RenamedAt:    renamedAt,
+		PhotoURL:     photoURL,
+		BannerURL:    bannerURL,
+		Description:  data.Description,
+		CityID:       data.CityID,
+		ContactPhone: data.ContactPhone,
+		ContactEmail: data.ContactEmail,
 	})
 	if errRepo != nil {
-		_ = os.Remove(photoURL)
+		_ = removeImage(ctx, alc.files, shopUploadDir, photoURL)
+		_ = removeImage(ctx, alc.files, shopUploadDir, bannerURL)
+
+		// Another shop took the handle after verifyShopHandle checked it.
+		if errors.Is(errRepo, gorm.ErrDuplicatedKey) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusConflict,
+				Err:  errShopHandleTaken,
+			}
+		}
+
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateShopByID: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
@@ -122,11 +220,21 @@ func (alc *ShopsUseCaseImpl) UpdateShopByID(ctx context.Context, shopID string,
 		}
 	}
 
+	if photoURL != "" && resShopRepo.PhotoURL != "" {
+		_ = removeImage(ctx, alc.files, shopUploadDir, resShopRepo.PhotoURL)
+	}
+	if bannerURL != "" && resShopRepo.BannerURL != "" {
+		_ = removeImage(ctx, alc.files, shopUploadDir, resShopRepo.BannerURL)
+	}
+
 	return "updated", nil
 }
 
-func (alc *ShopsUseCaseImpl) GetShopByID(ctx context.Context, shopID string) (res model.ShopResp, err *helper.ErrorStruct) {
+func (alc *ShopsUseCaseImpl) GetShopByID(ctx context.Context, shopID string) (res model.ShopProfileResp, err *helper.ErrorStruct) {
 	resRepo, errRepo := alc.shopsRepository.GetShopByID(ctx, shopID)
+	if errRepo == nil && resRepo.SuspendedAt != nil {
+		errRepo = gorm.ErrRecordNotFound
+	}
 	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
 			return res, &helper.ErrorStruct{
@@ -142,12 +250,134 @@ func (alc *ShopsUseCaseImpl) GetShopByID(ctx context.Context, shopID string) (re
 		}
 	}
 
-	res = model.ShopResp{
-		ID:       resRepo.ID,
-		ShopName: resRepo.ShopName,
-		PhotoURL: resRepo.PhotoURL,
+	return alc.shopProfileResp(ctx, resRepo)
+}
+
+// GetShopStorefront returns everything a shop page shows: the profile, the
+// best selling products in stock and how many products each category has.
+func (alc *ShopsUseCaseImpl) GetShopStorefront(ctx context.Context, shopID string) (res model.ShopStorefrontResp, err *helper.ErrorStruct) {
+	resRepo, errRepo := alc.shopsRepository.GetShopByID(ctx, shopID)
+	if errRepo == nil && resRepo.SuspendedAt != nil {
+		errRepo = gorm.ErrRecordNotFound
+	}
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	profile, err := alc.shopProfileResp(ctx, resRepo)
+	if err != nil {
+		return res, err
+	}
+
+	products, errRepo := alc.productsRepository.GetAllProducts(ctx, entity.FilterProducts{
+		Limit:       storefrontFeaturedProducts,
+		ShopID:      resRepo.ID,
+		Sort:        entity.ProductSortBestSell,
+		InStockOnly: true,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetAllProducts: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	featured := make([]model.ProductResp, 0, len(products))
+	for _, v := range products {
+		featured = append(featured, productToResp(alc.files, v))
+	}
+
+	counts, errRepo := alc.productsRepository.CountProductsByCategory(ctx, resRepo.ID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CountProductsByCategory: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	categories, errRepo := alc.categoriesRepository.GetCategories(ctx)
+	if errRepo != nil && !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCategories: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	shopCategories := []model.ShopCategoryResp{}
+	for _, v := range categories {
+		if counts[v.ID] == 0 {
+			continue
+		}
+
+		shopCategories = append(shopCategories, model.ShopCategoryResp{
+			ID:           v.ID,
+			CategoryName: v.CategoryName,
+			ProductCount: counts[v.ID],
+		})
+	}
+
+	res = model.ShopStorefrontResp{
+		Profile:          profile,
+		FeaturedProducts: featured,
+		Categories:       shopCategories,
+	}
+
+	return res, nil
+}
+
+// GetShopByHandle looks a shop up by its handle, the name it has in URLs.
+func (alc *ShopsUseCaseImpl) GetShopByHandle(ctx context.Context, handle string) (res model.ShopProfileResp, err *helper.ErrorStruct) {
+	resRepo, errRepo := alc.shopsRepository.GetShopBySlug(ctx, strings.ToLower(handle))
+	if errRepo == nil && resRepo.SuspendedAt != nil {
+		errRepo = gorm.ErrRecordNotFound
+	}
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopBySlug: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return alc.shopProfileResp(ctx, resRepo)
+}
+
+// CheckShopHandle tells whether a shop can take handle, and why not when it
+// cannot.
+func (alc *ShopsUseCaseImpl) CheckShopHandle(ctx context.Context, handle string) (res model.ShopHandleAvailabilityResp, err *helper.ErrorStruct) {
+	res.Handle = strings.ToLower(strings.TrimSpace(handle))
+
+	if err := alc.verifyShopHandle(ctx, res.Handle, 0); err != nil {
+		if err.Code != fiber.StatusBadRequest && err.Code != fiber.StatusConflict {
+			return res, err
+		}
+
+		res.Reason = err.Err.Error()
+		return res, nil
 	}
 
+	res.Available = true
 	return res, nil
 }
 
@@ -189,12 +419,26 @@ func (alc *ShopsUseCaseImpl) GetAllShops(ctx context.Context, params model.Shops
 		}
 	}
 
+	shopIDs := make([]uint, 0, len(resRepo))
 	for _, v := range resRepo {
-		shops = append(shops, model.ShopResp{
-			ID:       v.ID,
-			ShopName: v.ShopName,
-			PhotoURL: v.PhotoURL,
-		})
+		shopIDs = append(shopIDs, v.ID)
+	}
+
+	followers, errRepo := alc.shopsRepository.CountShopFollowers(ctx, shopIDs)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CountShopFollowers: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	for _, v := range resRepo {
+		shop := shopToResp(alc.files, v)
+		followerCount := followers[v.ID]
+		shop.FollowerCount = &followerCount
+
+		shops = append(shops, shop)
 	}
 
 	res = model.FilteredData{
@@ -205,3 +449,121 @@ func (alc *ShopsUseCaseImpl) GetAllShops(ctx context.Context, params model.Shops
 
 	return res, nil
 }
+
+func (alc *ShopsUseCaseImpl) shopProfileResp(ctx context.Context, shop entity.Shop) (res model.ShopProfileResp, err *helper.ErrorStruct) {
+	stats, errRepo := alc.shopsRepository.GetShopStats(ctx, shop.ID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopStats: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	// The city comes from an external API, the profile is still useful
+	// without it.
+	var city *model.CityResp
+	if shop.CityID != "" {
+		cityRepo, errRepo := alc.provcityRepository.GetCityByID(shop.CityID)
+		if errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetCityByID: %s", errRepo.Error()), errRepo)
+		} else {
+			city = &model.CityResp{
+				ID:         cityRepo.ID,
+				ProvinceID: cityRepo.ProvinceID,
+				Name:       cityRepo.Name,
+			}
+		}
+	}
+
+	res = model.ShopProfileResp{
+		ID:               shop.ID,
+		ShopName:         shop.ShopName,
+		Slug:             shop.Slug,
+		PhotoURL:         fileURL(alc.files, shopUploadDir, shop.PhotoURL),
+		Renditions:       imageRenditionsResp(alc.files, shopUploadDir, shop.PhotoURL),
+		BannerURL:        fileURL(alc.files, shopUploadDir, shop.BannerURL),
+		BannerRenditions: imageRenditionsResp(alc.files, shopUploadDir, shop.BannerURL),
+		Description:      shop.Description,
+		City:             city,
+		ContactPhone:     shop.ContactPhone,
+		ContactEmail:     shop.ContactEmail,
+		JoinedAt:         shop.CreatedAt,
+		Stats: model.ShopStatsResp{
+			ProductCount:  stats.ProductCount,
+			TotalSold:     stats.TotalSold,
+			FollowerCount: stats.FollowerCount,
+		},
+	}
+
+	return res, nil
+}
+
+func shopToResp(files storage.Storage, v entity.Shop) model.ShopResp {
+	return model.ShopResp{
+		ID:         v.ID,
+		ShopName:   v.ShopName,
+		Slug:       v.Slug,
+		PhotoURL:   fileURL(files, shopUploadDir, v.PhotoURL),
+		Renditions: imageRenditionsResp(files, shopUploadDir, v.PhotoURL),
+	}
+}
+
+// validateRename checks a change of the name or handle of shop. Renames are
+// limited to one per renameInterval so a shop cannot keep passing itself
+// off as others.
+func (alc *ShopsUseCaseImpl) validateRename(ctx context.Context, shop entity.Shop, data model.ShopReqUpdate) *helper.ErrorStruct {
+	if shop.RenamedAt != nil && alc.renameInterval > 0 {
+		if next := shop.RenamedAt.Add(alc.renameInterval); time.Now().Before(next) {
+			return &helper.ErrorStruct{
+				Code: fiber.StatusTooManyRequests,
+				Err:  fmt.Errorf("nama dan handle toko baru dapat diubah lagi setelah %s", next.Format("02-01-2006 15:04")),
+			}
+		}
+	}
+
+	if data.ShopName != "" && data.ShopName != shop.ShopName {
+		nameSlug := utils.GenerateSlug(data.ShopName)
+		if nameSlug == "" || utils.IsReservedShopHandle(nameSlug) {
+			return &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("nama toko tidak dapat digunakan"),
+			}
+		}
+	}
+
+	if data.Handle != "" && data.Handle != shop.Slug {
+		return alc.verifyShopHandle(ctx, data.Handle, shop.ID)
+	}
+
+	return nil
+}
+
+// verifyShopHandle returns a 400 error when handle is invalid or reserved
+// and a 409 error when a shop other than excludeShopID uses it.
+func (alc *ShopsUseCaseImpl) verifyShopHandle(ctx context.Context, handle string, excludeShopID uint) *helper.ErrorStruct {
+	if errHandle := utils.ValidateShopHandle(handle); errHandle != nil {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errHandle,
+		}
+	}
+
+	taken, errRepo := alc.shopsRepository.IsShopHandleTaken(ctx, handle, excludeShopID)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at IsShopHandleTaken: %s", errRepo.Error()), errRepo)
+		return &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	if taken {
+		return &helper.ErrorStruct{
+			Code: fiber.StatusConflict,
+			Err:  errShopHandleTaken,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/mailer"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	// stockAlertsBatchSize is how many alerts a single dispatch run emails.
+	stockAlertsBatchSize = 100
+	// stockAlertsMaxAttempts is how many failed sends an alert gets before
+	// it is given up on, so it stops taking a place in every batch.
+	stockAlertsMaxAttempts = 5
+	// stockAlertsRetryDelay is the wait after the first failed send, it
+	// doubles with every further failure.
+	stockAlertsRetryDelay = 5 * time.Minute
+)
+
+type StockAlertsUseCase interface {
+	GetMyStockAlerts(ctx context.Context, userID string, params model.StockAlertsFilter) (res model.FilteredData, err *helper.ErrorStruct)
+	DispatchStockAlertEmails(ctx context.Context) (res int, err *helper.ErrorStruct)
+}
+
+type StockAlertsUseCaseImpl struct {
+	stockAlertsRepository repository.StockAlertsRepository
+	shopsRepository       repository.ShopsRepository
+	usersRepository       repository.UsersRepository
+	mailer                mailer.Mailer
+}
+
+func NewStockAlertsUseCase(
+	stockAlertsRepository repository.StockAlertsRepository,
+	shopsRepository repository.ShopsRepository,
+	usersRepository repository.UsersRepository,
+	mailer mailer.Mailer,
+) StockAlertsUseCase {
+	return &StockAlertsUseCaseImpl{
+		stockAlertsRepository: stockAlertsRepository,
+		shopsRepository:       shopsRepository,
+		usersRepository:       usersRepository,
+		mailer:                mailer,
+	}
+}
+
+func (alc *StockAlertsUseCaseImpl) GetMyStockAlerts(ctx context.Context, userID string, params model.StockAlertsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	resShopRepo, errRepo := alc.shopsRepository.GetShopByUserID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("toko tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetShopByUserID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	limit, offset := func(limit, page int) (int, int) {
+		if limit < 1 {
+			limit = 10
+		}
+
+		var offset int
+		if page < 1 {
+			offset = 0
+		} else {
+			offset = (page - 1) * limit
+		}
+		return limit, offset
+	}(params.Limit, params.Page)
+
+	resRepo, errRepo := alc.stockAlertsRepository.GetStockAlertsByShopID(ctx, entity.FilterStockAlerts{
+		Limit:  limit,
+		Offset: offset,
+		ShopID: resShopRepo.ID,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetStockAlertsByShopID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	alerts := make([]model.StockAlertResp, 0, len(resRepo))
+	for _, v := range resRepo {
+		alerts = append(alerts, model.StockAlertResp{
+			ID:          v.ID,
+			Type:        v.Type,
+			ProductID:   v.ProductID,
+			ProductName: v.Product.ProductName,
+			Stock:       v.Stock,
+			Threshold:   v.Threshold,
+			EmailedAt:   v.EmailedAt,
+			CreatedAt:   v.CreatedAt,
+		})
+	}
+
+	res = model.FilteredData{
+		Data:  alerts,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+
+	return res, nil
+}
+
+// DispatchStockAlertEmails emails the alerts that are due, one email per
+// shop. Alerts whose email fails stay unsent and are retried with a growing
+// delay until stockAlertsMaxAttempts. It returns the number of alerts
+// emailed.
+func (alc *StockAlertsUseCaseImpl) DispatchStockAlertEmails(ctx context.Context) (res int, err *helper.ErrorStruct) {
+	resRepo, errRepo := alc.stockAlertsRepository.GetUnsentStockAlerts(ctx, stockAlertsBatchSize, stockAlertsMaxAttempts, time.Now())
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUnsentStockAlerts: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	var shopIDs []uint
+	alertsByShop := make(map[uint][]entity.StockAlert)
+	for _, v := range resRepo {
+		if _, ok := alertsByShop[v.ShopID]; !ok {
+			shopIDs = append(shopIDs, v.ShopID)
+		}
+		alertsByShop[v.ShopID] = append(alertsByShop[v.ShopID], v)
+	}
+
+	for _, shopID := range shopIDs {
+		alerts := alertsByShop[shopID]
+
+		attempts := 0
+		alertIDs := make([]uint, 0, len(alerts))
+		for _, v := range alerts {
+			alertIDs = append(alertIDs, v.ID)
+			if v.Attempts > attempts {
+				attempts = v.Attempts
+			}
+		}
+
+		if errSend := alc.sendStockAlertEmail(ctx, alerts); errSend != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at sendStockAlertEmail: %s", errSend.Error()), errSend)
+
+			nextAttemptAt := time.Now().Add(stockAlertsRetryDelay << attempts)
+			if errRepo := alc.stockAlertsRepository.MarkStockAlertsFailed(ctx, alertIDs, nextAttemptAt); errRepo != nil {
+				helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at MarkStockAlertsFailed: %s", errRepo.Error()), errRepo)
+			}
+			continue
+		}
+
+		if errRepo := alc.stockAlertsRepository.MarkStockAlertsEmailed(ctx, alertIDs, time.Now()); errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at MarkStockAlertsEmailed: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusInternalServerError,
+				Err:  errRepo,
+			}
+		}
+
+		res += len(alerts)
+	}
+
+	return res, nil
+}
+
+// sendStockAlertEmail emails alerts, which all belong to the same shop, to
+// the shop owner. An owner without an email address has nowhere to send
+// to, so the alerts count as sent.
+func (alc *StockAlertsUseCaseImpl) sendStockAlertEmail(ctx context.Context, alerts []entity.StockAlert) error {
+	shop := alerts[0].Shop
+
+	owner, err := alc.usersRepository.GetUserByID(ctx, fmt.Sprintf("%d", shop.UserID))
+	if err != nil {
+		return err
+	}
+
+	if owner.Email == "" {
+		return nil
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Halo %s,\n\nStok produk berikut di toko %s perlu diperhatikan:\n\n", owner.Name, shop.ShopName)
+	for _, v := range alerts {
+		if v.Type == entity.StockAlertOutOfStock {
+			fmt.Fprintf(&body, "- %s: stok habis\n", v.Product.ProductName)
+			continue
+		}
+		fmt.Fprintf(&body, "- %s: sisa %d (stok minimum %d)\n", v.Product.ProductName, v.Stock, v.Threshold)
+	}
+
+	return alc.mailer.Send(ctx, mailer.Message{
+		To:      []string{owner.Email},
+		Subject: fmt.Sprintf("Peringatan stok toko %s", shop.ShopName),
+		Body:    body.String(),
+	})
+}
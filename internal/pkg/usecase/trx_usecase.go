@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	"backend-evermos/internal/pkg/repository"
@@ -28,6 +29,10 @@ type TrxUseCaseImpl struct {
 	productsRepository      repository.ProductsRepository
 	addressesRepository     repository.AddressesRepository
 	productImagesRepository repository.ProductImagesRepository
+	variantsRepository      repository.ProductVariantsRepository
+	searchIndex             repository.ProductSearchIndex
+	files                   storage.Storage
+	inventory               InventoryService
 }
 
 func NewTrxUseCase(
@@ -37,6 +42,10 @@ func NewTrxUseCase(
 	productsRepository repository.ProductsRepository,
 	addressesRepository repository.AddressesRepository,
 	productImagesRepository repository.ProductImagesRepository,
+	variantsRepository repository.ProductVariantsRepository,
+	searchIndex repository.ProductSearchIndex,
+	files storage.Storage,
+	inventory InventoryService,
 ) TrxUseCase {
 	return &TrxUseCaseImpl{
 		trxRepository:           trxRepository,
@@ -45,6 +54,10 @@ func NewTrxUseCase(
 		productsRepository:      productsRepository,
 		addressesRepository:     addressesRepository,
 		productImagesRepository: productImagesRepository,
+		variantsRepository:      variantsRepository,
+		searchIndex:             searchIndex,
+		files:                   files,
+		inventory:               inventory,
 	}
 }
 
@@ -93,14 +106,49 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 			}
 		}
 
-		if resRepo.Stock < trxDetail.Quantity {
+		if productModerated(resRepo) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  fmt.Errorf("produk %s tidak dapat dibeli", resRepo.ProductName),
+			}
+		}
+
+		consumerPrice, stock := resRepo.ConsumerPrice, resRepo.Stock
+
+		var variant entity.ProductVariant
+		if trxDetail.VariantID != nil {
+			variant, errRepo = alc.variantsRepository.GetVariantByID(ctx, resRepo.ID, *trxDetail.VariantID)
+			if errRepo != nil {
+				if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+					return res, &helper.ErrorStruct{
+						Code: fiber.StatusNotFound,
+						Err:  errors.New("varian tidak ditemukan"),
+					}
+				}
+
+				helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetVariantByID: %s", errRepo.Error()), errRepo)
+				return res, &helper.ErrorStruct{
+					Code: fiber.StatusBadRequest,
+					Err:  errRepo,
+				}
+			}
+
+			consumerPrice, stock = variant.ConsumerPrice, variant.Stock
+		} else if len(resRepo.Variants) > 0 {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("varian produk harus dipilih"),
+			}
+		}
+
+		if stock < trxDetail.Quantity {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusBadRequest,
 				Err:  errors.New("stok tidak tersedia"),
 			}
 		}
 
-		price, err := strconv.Atoi(resRepo.ConsumerPrice)
+		price, err := strconv.Atoi(consumerPrice)
 		if err != nil {
 			return res, &helper.ErrorStruct{
 				Code: fiber.StatusInternalServerError,
@@ -111,20 +159,12 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 		productTotal := price * trxDetail.Quantity
 		grandTotal += productTotal
 
-		newStock := resRepo.Stock - trxDetail.Quantity
-
 		productTrx = append(productTrx, entity.ProductTrx{
-			Quantity:      trxDetail.Quantity,
-			TotalPrice:    productTotal,
-			ProductID:     resRepo.ID,
-			ProductName:   resRepo.ProductName,
-			Slug:          resRepo.Slug,
-			ResellerPrice: resRepo.ResellerPrice,
-			ConsumerPrice: resRepo.ConsumerPrice,
-			Stock:         newStock,
-			Description:   resRepo.Description,
-			ShopID:        resRepo.ShopID,
-			CategoryID:    resRepo.CategoryID,
+			Quantity:   trxDetail.Quantity,
+			TotalPrice: productTotal,
+			ProductID:  resRepo.ID,
+			ShopID:     resRepo.ShopID,
+			VariantID:  trxDetail.VariantID,
 		})
 	}
 
@@ -145,16 +185,9 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 		}
 
 		for _, data := range productTrx {
-			productLogID, err := alc.productLogsRepository.CreateProductLogs(txCtx, entity.ProductLog{
-				ProductID:     data.ProductID,
-				ProductName:   data.ProductName,
-				Slug:          data.Slug,
-				ResellerPrice: data.ResellerPrice,
-				ConsumerPrice: data.ConsumerPrice,
-				Description:   data.Description,
-				ShopID:        data.ShopID,
-				CategoryID:    data.CategoryID,
-			})
+			// The product is usually unchanged since its last edit, so
+			// the order points at the existing version.
+			productLogID, err := recordProductVersion(txCtx, alc.productsRepository, alc.productLogsRepository, data.ProductID, nil)
 			if err != nil {
 				return err
 			}
@@ -162,6 +195,7 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 			_, err = alc.trxDetailsRepository.CreateTrxDetails(txCtx, entity.TrxDetail{
 				TrxID:        trxID,
 				ProductLogID: productLogID,
+				VariantID:    data.VariantID,
 				ShopID:       data.ShopID,
 				Quantity:     data.Quantity,
 				TotalPrice:   data.TotalPrice,
@@ -170,9 +204,13 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 				return err
 			}
 
-			productID := fmt.Sprintf("%d", data.ProductID)
-			err = alc.productsRepository.UpdateProductByID(txCtx, productID, entity.Product{
-				Stock: data.Stock,
+			err = alc.inventory.ApplyStockChange(txCtx, StockChange{
+				ProductID: data.ProductID,
+				VariantID: data.VariantID,
+				Delta:     -data.Quantity,
+				Reason:    entity.StockReasonSale,
+				TrxID:     &trxID,
+				ActorID:   stockActorID(userID),
 			})
 			if err != nil {
 				return err
@@ -182,6 +220,15 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 		return nil
 	})
 	if errTransaction != nil {
+		// Stock is checked above, but another checkout may have taken it
+		// before this transaction ran.
+		if errors.Is(errTransaction, repository.ErrInsufficientStock) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errors.New("stok tidak tersedia"),
+			}
+		}
+
 		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at WithinTransaction: %s", errTransaction.Error()), errTransaction)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusInternalServerError,
@@ -189,6 +236,10 @@ func (alc *TrxUseCaseImpl) CreateTrx(ctx context.Context, userID string, data mo
 		}
 	}
 
+	for _, data := range productTrx {
+		syncProductIndex(ctx, alc.productsRepository, alc.searchIndex, data.ProductID)
+	}
+
 	return trxID, nil
 }
 
@@ -242,33 +293,41 @@ func (alc *TrxUseCaseImpl) GetTrxByID(ctx context.Context, userID string, trxID
 		var images []model.ProductImageResp
 		for _, img := range imgResRepo {
 			images = append(images, model.ProductImageResp{
-				ID:        img.ID,
-				ProductID: img.ProductID,
-				ImageURL:  img.PhotoURL,
+				ID:         img.ID,
+				ProductID:  img.ProductID,
+				ImageURL:   fileURL(alc.files, productUploadDir, img.PhotoURL),
+				Renditions: imageRenditionsResp(alc.files, productUploadDir, img.PhotoURL),
 			})
 		}
 
+		resellerPrice, consumerPrice := td.ProductLog.ResellerPrice, td.ProductLog.ConsumerPrice
+		variant := productLogVariantResp(td)
+		if variant != nil && variant.ConsumerPrice != "" {
+			resellerPrice, consumerPrice = variant.ResellerPrice, variant.ConsumerPrice
+		}
+
 		trxDetails = append(trxDetails, model.TrxDetailResp{
 			ProductLog: model.ProductLogResp{
 				ID:            productID,
 				ProductName:   td.ProductLog.ProductName,
 				Slug:          td.ProductLog.Slug,
-				ResellerPrice: td.ProductLog.ResellerPrice,
-				ConsumerPrice: td.ProductLog.ConsumerPrice,
+				ResellerPrice: resellerPrice,
+				ConsumerPrice: consumerPrice,
 				Description:   td.ProductLog.Description,
 				Shop: model.ShopInfo{
 					ShopName: td.ProductLog.Shop.ShopName,
-					PhotoURL: td.ProductLog.Shop.PhotoURL,
+					PhotoURL: fileURL(alc.files, shopUploadDir, td.ProductLog.Shop.PhotoURL),
 				},
 				Category: model.CategoryResp{
 					ID:           td.ProductLog.Category.ID,
 					CategoryName: td.ProductLog.Category.CategoryName,
 				},
-				Images: images,
+				Images:  images,
+				Variant: variant,
 			},
 			Shop: model.ShopInfo{
 				ShopName: td.ProductLog.Shop.ShopName,
-				PhotoURL: td.ProductLog.Shop.PhotoURL,
+				PhotoURL: fileURL(alc.files, shopUploadDir, td.ProductLog.Shop.PhotoURL),
 			},
 			Quantity:   td.Quantity,
 			TotalPrice: td.TotalPrice,
@@ -364,33 +423,41 @@ func (alc *TrxUseCaseImpl) GetAllTrx(ctx context.Context, userID string, params
 			var images []model.ProductImageResp
 			for _, img := range imgResRepo {
 				images = append(images, model.ProductImageResp{
-					ID:        img.ID,
-					ProductID: img.ProductID,
-					ImageURL:  img.PhotoURL,
+					ID:         img.ID,
+					ProductID:  img.ProductID,
+					ImageURL:   fileURL(alc.files, productUploadDir, img.PhotoURL),
+					Renditions: imageRenditionsResp(alc.files, productUploadDir, img.PhotoURL),
 				})
 			}
 
+			resellerPrice, consumerPrice := td.ProductLog.ResellerPrice, td.ProductLog.ConsumerPrice
+			variant := productLogVariantResp(td)
+			if variant != nil && variant.ConsumerPrice != "" {
+				resellerPrice, consumerPrice = variant.ResellerPrice, variant.ConsumerPrice
+			}
+
 			trxDetails = append(trxDetails, model.TrxDetailResp{
 				ProductLog: model.ProductLogResp{
 					ID:            productID,
 					ProductName:   td.ProductLog.ProductName,
 					Slug:          td.ProductLog.Slug,
-					ResellerPrice: td.ProductLog.ResellerPrice,
-					ConsumerPrice: td.ProductLog.ConsumerPrice,
+					ResellerPrice: resellerPrice,
+					ConsumerPrice: consumerPrice,
 					Description:   td.ProductLog.Description,
 					Shop: model.ShopInfo{
 						ShopName: td.ProductLog.Shop.ShopName,
-						PhotoURL: td.ProductLog.Shop.PhotoURL,
+						PhotoURL: fileURL(alc.files, shopUploadDir, td.ProductLog.Shop.PhotoURL),
 					},
 					Category: model.CategoryResp{
 						ID:           td.ProductLog.Category.ID,
 						CategoryName: td.ProductLog.Category.CategoryName,
 					},
-					Images: images,
+					Images:  images,
+					Variant: variant,
 				},
 				Shop: model.ShopInfo{
 					ShopName: td.ProductLog.Shop.ShopName,
-					PhotoURL: td.ProductLog.Shop.PhotoURL,
+					PhotoURL: fileURL(alc.files, shopUploadDir, td.ProductLog.Shop.PhotoURL),
 				},
 				Quantity:   td.Quantity,
 				TotalPrice: td.TotalPrice,
@@ -421,3 +488,20 @@ func (alc *TrxUseCaseImpl) GetAllTrx(ctx context.Context, userID string, params
 
 	return res, err
 }
+
+// productLogVariantResp returns the variant ordered in td as it was in the
+// product version, nil when no variant was ordered.
+func productLogVariantResp(td entity.TrxDetail) *model.ProductLogVariant {
+	if td.VariantID == nil {
+		return nil
+	}
+
+	for _, v := range td.ProductLog.Variants {
+		if v.ID == *td.VariantID {
+			variant := productLogVariantToResp(v)
+			return &variant
+		}
+	}
+
+	return &model.ProductLogVariant{ID: *td.VariantID}
+}
@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"backend-evermos/internal/utils"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UploadsUseCase interface {
+	ReconcileUploads(ctx context.Context, params model.ReconcileUploadsReq) (res model.ReconcileUploadsResp, err *helper.ErrorStruct)
+	BackfillRenditions(ctx context.Context, params model.BackfillRenditionsReq) (res model.BackfillRenditionsResp, err *helper.ErrorStruct)
+}
+
+// uploadDirectory lists the photos saved in the database that are stored
+// under one directory.
+type uploadDirectory struct {
+	name   string
+	photos []string
+}
+
+type UploadsUseCaseImpl struct {
+	productImagesRepository repository.ProductImagesRepository
+	variantsRepository      repository.ProductVariantsRepository
+	shopsRepository         repository.ShopsRepository
+	files                   storage.Storage
+}
+
+func NewUploadsUseCase(
+	productImagesRepository repository.ProductImagesRepository,
+	variantsRepository repository.ProductVariantsRepository,
+	shopsRepository repository.ShopsRepository,
+	files storage.Storage,
+) UploadsUseCase {
+	return &UploadsUseCaseImpl{
+		productImagesRepository: productImagesRepository,
+		variantsRepository:      variantsRepository,
+		shopsRepository:         shopsRepository,
+		files:                   files,
+	}
+}
+
+// ReconcileUploads compares the stored files with the photo URLs saved in
+// the database. Files nobody references are orphans and are deleted once
+// they are older than the grace period, so uploads whose transaction has not
+// committed yet are left alone. Referenced files that are not stored are
+// reported as missing, as are stored images lacking a rendition.
+func (alc *UploadsUseCaseImpl) ReconcileUploads(ctx context.Context, params model.ReconcileUploadsReq) (res model.ReconcileUploadsResp, err *helper.ErrorStruct) {
+	directories, err := alc.uploadDirectories(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	for _, directory := range directories {
+		if err := alc.reconcileDirectory(ctx, directory, params, &res); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// BackfillRenditions generates the missing renditions of stored images,
+// e.g. those uploaded before renditions existed, from the full size image.
+// Images that fail to decode are reported and left alone.
+func (alc *UploadsUseCaseImpl) BackfillRenditions(ctx context.Context, params model.BackfillRenditionsReq) (res model.BackfillRenditionsResp, err *helper.ErrorStruct) {
+	directories, err := alc.uploadDirectories(ctx)
+	if err != nil {
+		return res, err
+	}
+
+	for _, directory := range directories {
+		_, existing, err := alc.storedFiles(ctx, directory.name)
+		if err != nil {
+			return res, err
+		}
+
+		checked := make(map[string]bool)
+		for _, photo := range directory.photos {
+			key := path.Join(directory.name, photo)
+			if isAbsoluteURL(photo) || checked[key] || !existing[key] {
+				continue
+			}
+
+			checked[key] = true
+			res.Checked++
+			if !missingRendition(directory.name, photo, existing) {
+				continue
+			}
+
+			res.Missing = append(res.Missing, key)
+			if params.DryRun {
+				continue
+			}
+
+			if errGenerate := alc.generateRenditions(ctx, directory.name, photo); errGenerate != nil {
+				helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at generateRenditions: %s", errGenerate.Error()), errGenerate)
+				res.Failed = append(res.Failed, key)
+				continue
+			}
+			res.Generated = append(res.Generated, key)
+		}
+	}
+
+	return res, nil
+}
+
+func (alc *UploadsUseCaseImpl) generateRenditions(ctx context.Context, directory string, fileName string) error {
+	body, err := alc.files.Get(ctx, path.Join(directory, fileName))
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	renditions, err := utils.GenerateRenditions(fileName, data)
+	if err != nil {
+		return err
+	}
+
+	for _, rendition := range renditions {
+		if err := alc.files.Put(ctx, path.Join(directory, rendition.FileName), bytes.NewReader(rendition.Data), rendition.ContentType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (alc *UploadsUseCaseImpl) uploadDirectories(ctx context.Context) ([]uploadDirectory, *helper.ErrorStruct) {
+	productPhotos, errRepo := alc.productImagesRepository.GetPhotoURLs(ctx)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetPhotoURLs: %s", errRepo.Error()), errRepo)
+		return nil, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	variantPhotos, errRepo := alc.variantsRepository.GetPhotoURLs(ctx)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetPhotoURLs: %s", errRepo.Error()), errRepo)
+		return nil, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	shopPhotos, errRepo := alc.shopsRepository.GetPhotoURLs(ctx)
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetPhotoURLs: %s", errRepo.Error()), errRepo)
+		return nil, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errRepo,
+		}
+	}
+
+	return []uploadDirectory{
+		{name: productUploadDir, photos: append(productPhotos, variantPhotos...)},
+		{name: shopUploadDir, photos: shopPhotos},
+	}, nil
+}
+
+func (alc *UploadsUseCaseImpl) storedFiles(ctx context.Context, directory string) ([]storage.FileInfo, map[string]bool, *helper.ErrorStruct) {
+	stored, errStorage := alc.files.List(ctx, directory+"/")
+	if errStorage != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at List: %s", errStorage.Error()), errStorage)
+		return nil, nil, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal membaca daftar file"),
+		}
+	}
+
+	existing := make(map[string]bool, len(stored))
+	for _, file := range stored {
+		existing[file.Key] = true
+	}
+
+	return stored, existing, nil
+}
+
+func missingRendition(directory string, photo string, existing map[string]bool) bool {
+	for _, rendition := range utils.ImageRenditions {
+		if !existing[path.Join(directory, utils.RenditionFileName(photo, rendition.Name))] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (alc *UploadsUseCaseImpl) reconcileDirectory(ctx context.Context, directory uploadDirectory, params model.ReconcileUploadsReq, res *model.ReconcileUploadsResp) *helper.ErrorStruct {
+	stored, existing, err := alc.storedFiles(ctx, directory.name)
+	if err != nil {
+		return err
+	}
+
+	referenced := make(map[string]bool)
+	for _, photo := range directory.photos {
+		if isAbsoluteURL(photo) {
+			continue
+		}
+
+		key := path.Join(directory.name, photo)
+		if !referenced[key] {
+			if !existing[key] {
+				res.Missing = append(res.Missing, key)
+			} else if missingRendition(directory.name, photo, existing) {
+				res.MissingRenditions = append(res.MissingRenditions, key)
+			}
+		}
+
+		referenced[key] = true
+		for _, rendition := range utils.ImageRenditions {
+			referenced[path.Join(directory.name, utils.RenditionFileName(photo, rendition.Name))] = true
+		}
+	}
+
+	cutoff := time.Now().Add(-params.GracePeriod)
+	for _, file := range stored {
+		res.Checked++
+		if referenced[file.Key] {
+			continue
+		}
+
+		res.Orphans = append(res.Orphans, file.Key)
+		if params.DryRun || file.LastModified.After(cutoff) {
+			continue
+		}
+
+		if errStorage := alc.files.Delete(ctx, file.Key); errStorage != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at Delete: %s", errStorage.Error()), errStorage)
+			continue
+		}
+		res.Deleted = append(res.Deleted, file.Key)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/mailer"
 	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/model"
 	usersModel "backend-evermos/internal/pkg/model"
@@ -11,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -33,17 +35,23 @@ type UsersUseCaseImpl struct {
 	usersRepository     repository.UsersRepository
 	addressesRepository repository.AddressesRepository
 	provcityRepository  repository.ProvcityRepository
+	mailer              mailer.Mailer
+	emailVerification   EmailVerificationConf
 }
 
 func NewUsersUseCase(
 	usersRepository repository.UsersRepository,
 	addressesRepository repository.AddressesRepository,
 	provcityRepository repository.ProvcityRepository,
+	mailer mailer.Mailer,
+	emailVerification EmailVerificationConf,
 ) UsersUseCase {
 	return &UsersUseCaseImpl{
 		usersRepository:     usersRepository,
 		addressesRepository: addressesRepository,
 		provcityRepository:  provcityRepository,
+		mailer:              mailer,
+		emailVerification:   emailVerification,
 	}
 }
 
@@ -141,20 +149,39 @@ func (alc *UsersUseCaseImpl) UpdateMyProfile(ctx context.Context, userID string,
 		}
 	}
 
-	if errRepo := alc.usersRepository.VerifyPhoneNumber(ctx, data.PhoneNumber); errRepo != nil {
+	user, errRepo := alc.usersRepository.GetUserByID(ctx, userID)
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("user tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetUserByID: %s", errRepo.Error()), errRepo)
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
-	if errRepo := alc.usersRepository.VerifyEmail(ctx, data.Email); errRepo != nil {
+	if errRepo := alc.usersRepository.VerifyPhoneNumber(ctx, data.PhoneNumber); errRepo != nil {
 		return res, &helper.ErrorStruct{
 			Code: fiber.StatusBadRequest,
 			Err:  errRepo,
 		}
 	}
 
+	emailChanged := data.Email != "" && data.Email != user.Email
+	if emailChanged {
+		if errRepo := alc.usersRepository.VerifyEmail(ctx, data.Email); errRepo != nil {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusBadRequest,
+				Err:  errRepo,
+			}
+		}
+	}
+
 	hashPass, errHash := utils.HashPassword(data.Password)
 	if errHash != nil {
 		log.Println(errHash)
@@ -173,16 +200,24 @@ func (alc *UsersUseCaseImpl) UpdateMyProfile(ctx context.Context, userID string,
 		}
 	}
 
-	errRepo := alc.usersRepository.UpdateUserByID(ctx, userID, entity.User{
-		Name:        data.Name,
-		Password:    hashPass,
-		PhoneNumber: data.PhoneNumber,
-		BirthDate:   birthDate,
-		JobTitle:    data.JobTitle,
-		About:       data.About,
-		Email:       data.Email,
-		ProvinceID:  data.ProvinceID,
-		CityID:      data.CityID,
+	// A new password logs every session out.
+	var tokensRevokedAt *time.Time
+	if data.Password != "" {
+		now := time.Now().Truncate(time.Millisecond)
+		tokensRevokedAt = &now
+	}
+
+	errRepo = alc.usersRepository.UpdateUserByID(ctx, userID, entity.User{
+		Name:            data.Name,
+		Password:        hashPass,
+		PhoneNumber:     data.PhoneNumber,
+		BirthDate:       birthDate,
+		JobTitle:        data.JobTitle,
+		About:           data.About,
+		Email:           data.Email,
+		ProvinceID:      data.ProvinceID,
+		CityID:          data.CityID,
+		TokensRevokedAt: tokensRevokedAt,
 	})
 	if errRepo != nil {
 		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
@@ -199,6 +234,26 @@ func (alc *UsersUseCaseImpl) UpdateMyProfile(ctx context.Context, userID string,
 		}
 	}
 
+	// A new email has to be verified again, a link to it is mailed right
+	// away. The profile is saved either way, a failed email can be resent.
+	if emailChanged {
+		if errRepo := alc.usersRepository.UpdateEmailVerifiedAt(ctx, userID, nil); errRepo != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at UpdateEmailVerifiedAt: %s", errRepo.Error()), errRepo)
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusInternalServerError,
+				Err:  errRepo,
+			}
+		}
+
+		user.Email = data.Email
+		if data.Name != "" {
+			user.Name = data.Name
+		}
+		if errSend := sendEmailVerification(ctx, alc.usersRepository, alc.mailer, alc.emailVerification, user); errSend != nil {
+			helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at sendEmailVerification: %s", errSend.Error()), errSend)
+		}
+	}
+
 	return "updated", nil
 }
 
@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"backend-evermos/internal/helper"
+	"backend-evermos/internal/infrastructure/storage"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/model"
+	"backend-evermos/internal/pkg/repository"
+	"backend-evermos/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type WishlistsUseCase interface {
+	AddWishlist(ctx context.Context, userID string, data model.WishlistReqCreate) (res uint, err *helper.ErrorStruct)
+	GetMyWishlists(ctx context.Context, userID string, params model.WishlistsFilter) (res model.FilteredData, err *helper.ErrorStruct)
+	DeleteWishlist(ctx context.Context, userID string, productID string) (res string, err *helper.ErrorStruct)
+}
+
+type WishlistsUseCaseImpl struct {
+	wishlistsRepository repository.WishlistsRepository
+	productsRepository  repository.ProductsRepository
+	files               storage.Storage
+}
+
+func NewWishlistsUseCase(
+	wishlistsRepository repository.WishlistsRepository,
+	productsRepository repository.ProductsRepository,
+	files storage.Storage,
+) WishlistsUseCase {
+	return &WishlistsUseCaseImpl{
+		wishlistsRepository: wishlistsRepository,
+		productsRepository:  productsRepository,
+		files:               files,
+	}
+}
+
+func (alc *WishlistsUseCaseImpl) AddWishlist(ctx context.Context, userID string, data model.WishlistReqCreate) (res uint, err *helper.ErrorStruct) {
+	if errValidate := helper.Validate.Struct(data); errValidate != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errValidate,
+		}
+	}
+
+	productID := fmt.Sprintf("%d", data.ProductID)
+	resProductRepo, errRepo := alc.productsRepository.GetProductByID(ctx, productID)
+	if errRepo == nil && productModerated(resProductRepo) {
+		errRepo = gorm.ErrRecordNotFound
+	}
+	if errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("produk tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetProductByID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	errRepo = alc.wishlistsRepository.VerifyWishlistAvailability(ctx, userID, productID)
+	if errRepo == nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("produk sudah ada di wishlist"),
+		}
+	}
+
+	if !errors.Is(errRepo, gorm.ErrRecordNotFound) {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at VerifyWishlistAvailability: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	userIDNum, errConv := utils.ConvertStringToUint(userID)
+	if errConv != nil {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errConv,
+		}
+	}
+
+	resRepo, errRepo := alc.wishlistsRepository.CreateWishlist(ctx, entity.Wishlist{
+		UserID:    userIDNum,
+		ProductID: data.ProductID,
+	})
+	if errors.Is(errRepo, gorm.ErrDuplicatedKey) {
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errors.New("produk sudah ada di wishlist"),
+		}
+	}
+
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at CreateWishlist: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusInternalServerError,
+			Err:  errors.New("gagal menambahkan wishlist"),
+		}
+	}
+
+	return resRepo, nil
+}
+
+func (alc *WishlistsUseCaseImpl) GetMyWishlists(ctx context.Context, userID string, params model.WishlistsFilter) (res model.FilteredData, err *helper.ErrorStruct) {
+	var wishlists []model.WishlistResp
+
+	limit, offset := func(limit, page int) (int, int) {
+		if limit < 1 {
+			limit = 10
+		}
+
+		var offset int
+		if page < 1 {
+			offset = 0
+		} else {
+			offset = (page - 1) * limit
+		}
+		return limit, offset
+	}(params.Limit, params.Page)
+
+	resRepo, errRepo := alc.wishlistsRepository.GetWishlistsByUserID(ctx, userID, entity.FilterWishlists{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if errRepo != nil {
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at GetWishlistsByUserID: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	for _, v := range resRepo {
+		product := productToResp(alc.files, v.Product)
+		product.IsWishlisted = true
+
+		wishlists = append(wishlists, model.WishlistResp{
+			ID:      v.ID,
+			Product: product,
+		})
+	}
+
+	res = model.FilteredData{
+		Data:  wishlists,
+		Page:  params.Page,
+		Limit: params.Limit,
+	}
+
+	return res, nil
+}
+
+func (alc *WishlistsUseCaseImpl) DeleteWishlist(ctx context.Context, userID string, productID string) (res string, err *helper.ErrorStruct) {
+	if errRepo := alc.wishlistsRepository.DeleteWishlist(ctx, userID, productID); errRepo != nil {
+		if errors.Is(errRepo, gorm.ErrRecordNotFound) {
+			return res, &helper.ErrorStruct{
+				Code: fiber.StatusNotFound,
+				Err:  errors.New("wishlist tidak ditemukan"),
+			}
+		}
+
+		helper.Logger(helper.LoggerLevelError, fmt.Sprintf("Error at DeleteWishlist: %s", errRepo.Error()), errRepo)
+		return res, &helper.ErrorStruct{
+			Code: fiber.StatusBadRequest,
+			Err:  errRepo,
+		}
+	}
+
+	return "deleted", nil
+}
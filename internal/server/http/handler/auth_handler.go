@@ -4,6 +4,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	authcontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/usecase"
 )
 
@@ -13,4 +14,12 @@ func AuthRoute(r fiber.Router, AuthUsc usecase.AuthUseCase) {
 	booksAPI := r.Group("/auth")
 	booksAPI.Post("/register", controller.Register)
 	booksAPI.Post("/login", controller.Login)
+	booksAPI.Post("/refresh", controller.RefreshToken)
+	booksAPI.Post("/logout", MiddlewareAuth, controller.Logout)
+	booksAPI.Post("/forgot-password", controller.ForgotPassword)
+	booksAPI.Post("/reset-password", controller.ResetPassword)
+	booksAPI.Get("/verify-email", controller.VerifyEmail)
+	booksAPI.Post("/verify-email/resend", MiddlewareAuth, controller.ResendEmailVerification)
+
+	r.Put("/admin/login/unlock", MiddlewareAuth, MiddlewarePermission(entity.PermLoginUnlock), controller.UnlockLogin)
 }
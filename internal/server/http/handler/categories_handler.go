@@ -2,6 +2,7 @@ package handler
 
 import (
 	categoriescontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,10 +11,15 @@ import (
 func CategoriesRoute(r fiber.Router, CategoryUsc usecase.CategoriesUseCase) {
 	controller := categoriescontroller.NewCategoriesController(CategoryUsc)
 
+	manageCategories := MiddlewarePermission(entity.PermCategoriesManage)
+
 	CategoriesAPI := r.Group("/category")
 	CategoriesAPI.Get("", controller.GetCategories)
+	CategoriesAPI.Get("/tree", controller.GetCategoryTree)
 	CategoriesAPI.Get("/:id", controller.GetCategoryByID)
-	CategoriesAPI.Post("", MiddlewareAuth, MiddlewareAuthAdmin, controller.AddCategory)
-	CategoriesAPI.Put("/:id", MiddlewareAuth, MiddlewareAuthAdmin, controller.UpdateCategoryByID)
-	CategoriesAPI.Delete("/:id", MiddlewareAuth, MiddlewareAuthAdmin, controller.DeleteCategoryByID)
+	CategoriesAPI.Post("", MiddlewareAuth, manageCategories, controller.AddCategory)
+	CategoriesAPI.Put("/:id", MiddlewareAuth, manageCategories, controller.UpdateCategoryByID)
+	CategoriesAPI.Put("/:id/move", MiddlewareAuth, manageCategories, controller.MoveCategory)
+	CategoriesAPI.Post("/:id/merge", MiddlewareAuth, manageCategories, controller.MergeCategory)
+	CategoriesAPI.Delete("/:id", MiddlewareAuth, manageCategories, controller.DeleteCategoryByID)
 }
@@ -2,61 +2,130 @@ package handler
 
 import (
 	"backend-evermos/internal/helper"
+	"backend-evermos/internal/pkg/usecase"
 	"backend-evermos/internal/utils"
 	"fmt"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func MiddlewareAuth(ctx *fiber.Ctx) error {
-	token := ctx.Get("token")
-	if token == "" {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(helper.Response{
-			Status:  false,
-			Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
-			Errors:  []string{"Unauthorized"},
-			Data:    nil,
-		})
+// authUseCase rejects revoked access tokens and loads the roles of their
+// users, it is set by InitAuthMiddleware before the routes are registered.
+var authUseCase usecase.AuthUseCase
 
-		// return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		// 	"message": "unauthenticated",
-		// })
-	}
+func InitAuthMiddleware(authUsc usecase.AuthUseCase) {
+	authUseCase = authUsc
+}
 
-	claims, err := utils.DecodeToken(token)
-	if err != nil {
+func MiddlewareAuth(ctx *fiber.Ctx) error {
+	user, ok := authenticate(ctx)
+	if !ok {
 		return ctx.Status(fiber.StatusUnauthorized).JSON(helper.Response{
 			Status:  false,
 			Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
 			Errors:  []string{"Unauthorized"},
 			Data:    nil,
 		})
-		// return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		// 	"message": "unauthenticated",
-		// })
 	}
 
-	ctx.Locals("userid", claims["id"])
-	ctx.Locals("useremail", claims["email"])
-	ctx.Locals("is_admin", claims["is_admin"])
+	helper.SetAuthUser(ctx, user)
 
 	// Go to next middleware:
 	return ctx.Next()
 }
 
-func MiddlewareAuthAdmin(ctx *fiber.Ctx) error {
-	isAdmin := ctx.Locals("is_admin") // diasumsikan sudah di-set di middleware JWT
-	if isAdmin != true {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(helper.Response{
-			Status:  false,
-			Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
-			Errors:  []string{"Unauthorized"},
-			Data:    nil,
-		})
-		// return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-		// 	"error": "Access denied. Admins only.",
-		// })
+// MiddlewareAuthOptional populates the auth user when a valid token is sent
+// but never rejects the request, for public routes that personalise output.
+func MiddlewareAuthOptional(ctx *fiber.Ctx) error {
+	if user, ok := authenticate(ctx); ok {
+		helper.SetAuthUser(ctx, user)
 	}
 
 	return ctx.Next()
 }
+
+// MiddlewarePermission must run after MiddlewareAuth, it answers 401 when no
+// user is authenticated and 403 when none of their roles grants permission.
+func MiddlewarePermission(permission string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		user, ok := helper.GetAuthUser(ctx)
+		if !ok {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(helper.Response{
+				Status:  false,
+				Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
+				Errors:  []string{"Unauthorized"},
+				Data:    nil,
+			})
+		}
+
+		if !user.HasPermission(permission) {
+			return ctx.Status(fiber.StatusForbidden).JSON(helper.Response{
+				Status:  false,
+				Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
+				Errors:  []string{"Forbidden"},
+				Data:    nil,
+			})
+		}
+
+		return ctx.Next()
+	}
+}
+
+// MiddlewareVerifiedEmail must run after MiddlewareAuth, it rejects users
+// without a verified email when the policy restricts action to them.
+func MiddlewareVerifiedEmail(action string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if err := authUseCase.RequireVerifiedEmail(ctx.Context(), helper.AuthUserID(ctx), action); err != nil {
+			return ctx.Status(err.Code).JSON(helper.Response{
+				Status:  false,
+				Message: fmt.Sprintf("Failed to %s data", ctx.Method()),
+				Errors:  []string{err.Err.Error()},
+				Data:    nil,
+			})
+		}
+
+		return ctx.Next()
+	}
+}
+
+// bearerToken reads the access token from the Authorization Bearer header,
+// falling back to the legacy token header.
+func bearerToken(ctx *fiber.Ctx) string {
+	header := strings.TrimSpace(ctx.Get(fiber.HeaderAuthorization))
+	if scheme, token, found := strings.Cut(header, " "); found && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ctx.Get("token")
+}
+
+// authenticate decodes the request token and loads the current roles of its
+// user, ok is false when the token is missing, invalid or revoked.
+func authenticate(ctx *fiber.Ctx) (user helper.AuthUser, ok bool) {
+	token := bearerToken(ctx)
+	if token == "" {
+		return user, false
+	}
+
+	claims, err := utils.DecodeToken(token)
+	if err != nil {
+		return user, false
+	}
+
+	user.ID, _ = claims["id"].(string)
+	user.Email, _ = claims["email"].(string)
+	if user.ID == "" {
+		return user, false
+	}
+
+	access, errAccess := authUseCase.VerifyAccessToken(ctx.Context(), user.ID, utils.TokenIssuedAt(claims))
+	if errAccess != nil {
+		return user, false
+	}
+
+	user.Roles = access.Roles
+	user.Permissions = access.Permissions
+
+	return user, true
+}
@@ -0,0 +1,21 @@
+package handler
+
+import (
+	moderationcontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ModerationRoute(r fiber.Router, ModerationUsc usecase.ModerationUseCase) {
+	controller := moderationcontroller.NewModerationController(ModerationUsc)
+
+	moderateShops := MiddlewarePermission(entity.PermShopsModerate)
+	moderateProducts := MiddlewarePermission(entity.PermProductsModerate)
+
+	r.Put("/admin/toko/:id/suspend", MiddlewareAuth, moderateShops, controller.SuspendShop)
+	r.Put("/admin/toko/:id/unsuspend", MiddlewareAuth, moderateShops, controller.UnsuspendShop)
+	r.Put("/admin/product/:id/takedown", MiddlewareAuth, moderateProducts, controller.TakedownProduct)
+	r.Put("/admin/product/:id/restore", MiddlewareAuth, moderateProducts, controller.RestoreProduct)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	productscontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,10 +11,22 @@ import (
 func ProductsRoute(r fiber.Router, ProductUsc usecase.ProductsUseCase) {
 	controller := productscontroller.NewProductsController(ProductUsc)
 
+	sell := MiddlewarePermission(entity.PermProductsSell)
+
 	ProductsAPI := r.Group("/product")
-	ProductsAPI.Post("", MiddlewareAuth, controller.AddProduct)
-	ProductsAPI.Get("", controller.GetAllProducts)
+	ProductsAPI.Post("", MiddlewareAuth, sell, MiddlewareVerifiedEmail(usecase.VerifiedEmailForSelling), controller.AddProduct)
+	ProductsAPI.Get("", MiddlewareAuthOptional, controller.GetAllProducts)
 	ProductsAPI.Get("/:id", controller.GetProductByID)
-	ProductsAPI.Put("/:id", MiddlewareAuth, controller.UpdateProductByID)
-	ProductsAPI.Delete("/:id", MiddlewareAuth, controller.DeleteProductByID)
+	ProductsAPI.Put("/:id", MiddlewareAuth, sell, controller.UpdateProductByID)
+	ProductsAPI.Delete("/:id", MiddlewareAuth, sell, controller.DeleteProductByID)
+	ProductsAPI.Get("/:id/stock-history", MiddlewareAuth, sell, controller.GetStockHistory)
+	ProductsAPI.Get("/:id/history", MiddlewareAuth, sell, controller.GetProductHistory)
+
+	ProductsAPI.Post("/:id/photos", MiddlewareAuth, sell, controller.AddProductPhotos)
+	ProductsAPI.Put("/:id/photos/order", MiddlewareAuth, sell, controller.ReorderProductPhotos)
+	ProductsAPI.Put("/:id/photos/:photoId", MiddlewareAuth, sell, controller.ReplaceProductPhoto)
+	ProductsAPI.Put("/:id/photos/:photoId/primary", MiddlewareAuth, sell, controller.SetPrimaryProductPhoto)
+	ProductsAPI.Delete("/:id/photos/:photoId", MiddlewareAuth, sell, controller.DeleteProductPhoto)
+
+	r.Get("/toko/:shopSlug/product/:slug", controller.GetProductBySlug)
 }
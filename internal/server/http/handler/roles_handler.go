@@ -0,0 +1,18 @@
+package handler
+
+import (
+	rolescontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func RolesRoute(r fiber.Router, RolesUsc usecase.RolesUseCase) {
+	controller := rolescontroller.NewRolesController(RolesUsc)
+
+	manageRoles := MiddlewarePermission(entity.PermRolesManage)
+	r.Get("/admin/roles", MiddlewareAuth, manageRoles, controller.GetRoles)
+	r.Get("/admin/user/:id/roles", MiddlewareAuth, manageRoles, controller.GetUserRoles)
+	r.Put("/admin/user/:id/roles", MiddlewareAuth, manageRoles, controller.UpdateUserRoles)
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	shopfollowscontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func ShopFollowsRoute(r fiber.Router, ShopFollowUsc usecase.ShopFollowsUseCase) {
+	controller := shopfollowscontroller.NewShopFollowsController(ShopFollowUsc)
+
+	r.Post("/toko/:id/follow", MiddlewareAuth, controller.FollowShop)
+	r.Delete("/toko/:id/follow", MiddlewareAuth, controller.UnfollowShop)
+	r.Get("/user/following", MiddlewareAuth, controller.GetMyFollowing)
+	r.Get("/user/feed", MiddlewareAuth, controller.GetMyFeed)
+}
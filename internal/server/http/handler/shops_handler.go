@@ -2,6 +2,7 @@ package handler
 
 import (
 	shopscontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
 	"backend-evermos/internal/pkg/usecase"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,9 +11,14 @@ import (
 func ShopsRoute(r fiber.Router, ShopUsc usecase.ShopsUseCase) {
 	controller := shopscontroller.NewShopsController(ShopUsc)
 
+	sell := MiddlewarePermission(entity.PermProductsSell)
+
 	shopsAPI := r.Group("/toko")
 	shopsAPI.Get("/my", MiddlewareAuth, controller.GetMyShop)
-	shopsAPI.Put("/:id", MiddlewareAuth, controller.UpdateShopByID)
+	shopsAPI.Get("/handle-check", controller.CheckShopHandle)
+	shopsAPI.Get("/handle/:handle", controller.GetShopByHandle)
+	shopsAPI.Put("/:id", MiddlewareAuth, sell, controller.UpdateShopByID)
+	shopsAPI.Get("/:id/storefront", controller.GetShopStorefront)
 	shopsAPI.Get("/:id", MiddlewareAuth, controller.GetShopByID)
 	shopsAPI.Get("", controller.GetAllShops)
 }
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	stockalertscontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/entity"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func StockAlertsRoute(r fiber.Router, StockAlertUsc usecase.StockAlertsUseCase) {
+	controller := stockalertscontroller.NewStockAlertsController(StockAlertUsc)
+
+	stockAlertsAPI := r.Group("/toko/my/alerts")
+	stockAlertsAPI.Get("", MiddlewareAuth, MiddlewarePermission(entity.PermProductsSell), controller.GetMyStockAlerts)
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	wishlistscontroller "backend-evermos/internal/pkg/controller"
+	"backend-evermos/internal/pkg/usecase"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func WishlistsRoute(r fiber.Router, WishlistUsc usecase.WishlistsUseCase) {
+	controller := wishlistscontroller.NewWishlistsController(WishlistUsc)
+
+	wishlistsAPI := r.Group("/user/wishlist")
+	wishlistsAPI.Get("", MiddlewareAuth, controller.GetMyWishlists)
+	wishlistsAPI.Post("", MiddlewareAuth, controller.AddWishlist)
+	wishlistsAPI.Delete("/:productId", MiddlewareAuth, controller.DeleteWishlist)
+}
@@ -4,11 +4,25 @@ import (
 	route "backend-evermos/internal/server/http/handler"
 
 	"backend-evermos/internal/infrastructure/container"
+	"backend-evermos/internal/infrastructure/storage"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// uploadCacheMaxAge is how long clients may cache uploaded files. Uploads
+// are never overwritten in place, a new upload always gets a new name.
+const uploadCacheMaxAge = 7 * 24 * 60 * 60
+
 func HTTPRouteInit(r *fiber.App, containerConf *container.Container) {
+	if local, ok := containerConf.Storage.(*storage.LocalStorage); ok {
+		r.Static(storage.LocalURLPrefix, local.Root(), fiber.Static{
+			ByteRange: true,
+			MaxAge:    uploadCacheMaxAge,
+		})
+	}
+
+	route.InitAuthMiddleware(containerConf.AuthUsc)
+
 	api := r.Group("/api/v1") // /api
 
 	route.AuthRoute(api, containerConf.AuthUsc)
@@ -18,4 +32,9 @@ func HTTPRouteInit(r *fiber.App, containerConf *container.Container) {
 	route.CategoriesRoute(api, containerConf.CategoriesUsc)
 	route.TrxRoute(api, containerConf.TrxUsc)
 	route.ProvcityRoute(api, containerConf.ProvcityUsc)
+	route.WishlistsRoute(api, containerConf.WishlistsUsc)
+	route.ShopFollowsRoute(api, containerConf.ShopFollowsUsc)
+	route.RolesRoute(api, containerConf.RolesUsc)
+	route.ModerationRoute(api, containerConf.ModerationUsc)
+	route.StockAlertsRoute(api, containerConf.StockAlertsUsc)
 }
@@ -0,0 +1,335 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("format gambar harus JPEG, PNG atau WebP")
+	ErrImageTooLarge    = errors.New("ukuran gambar terlalu besar")
+)
+
+// maxImagePixels guards against decompression bombs: small files that
+// decode into huge bitmaps.
+const maxImagePixels = 40_000_000
+
+const jpegQuality = 85
+
+type ImageRendition struct {
+	Name    string
+	MaxSize int
+}
+
+// ImageRenditions are generated for every uploaded image, each scaled so
+// its longest side fits MaxSize. Images smaller than that are not upscaled.
+var ImageRenditions = []ImageRendition{
+	{Name: "thumbnail", MaxSize: 200},
+	{Name: "medium", MaxSize: 600},
+	{Name: "large", MaxSize: 1200},
+}
+
+type EncodedImage struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// ProcessImage validates an uploaded image by its content rather than its
+// filename and re-encodes it without metadata. It returns the full size
+// image followed by its renditions, named after the full size image as
+// described by RenditionFileName.
+func ProcessImage(file *multipart.FileHeader, maxBytes int64) (res []EncodedImage, err error) {
+	if maxBytes > 0 && file.Size > maxBytes {
+		return nil, fmt.Errorf("%w, maksimal %d KB", ErrImageTooLarge, maxBytes/1024)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	reader := io.Reader(src)
+	if maxBytes > 0 {
+		reader = io.LimitReader(src, maxBytes+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("%w, maksimal %d KB", ErrImageTooLarge, maxBytes/1024)
+	}
+
+	img, format, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ext, contentType := ".jpg", "image/jpeg"
+	if format == "image/png" || !isOpaque(img) {
+		ext, contentType = ".png", "image/png"
+	}
+
+	fileName := fmt.Sprintf("%012x%s", time.Now().UnixNano(), ext)
+	encoded, err := encodeImage(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, EncodedImage{FileName: fileName, ContentType: contentType, Data: encoded})
+
+	renditions, err := encodeRenditions(img, fileName, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(res, renditions...), nil
+}
+
+// GenerateRenditions returns the renditions of an already stored image,
+// e.g. one uploaded before renditions were generated, named after fileName.
+func GenerateRenditions(fileName string, data []byte) ([]EncodedImage, error) {
+	img, format, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "image/jpeg"
+	if format == "image/png" || !isOpaque(img) {
+		contentType = "image/png"
+	}
+
+	return encodeRenditions(img, fileName, contentType)
+}
+
+// RenditionFileName returns the file name of a rendition of fileName, e.g.
+// "abc.jpg" becomes "abc_thumbnail.jpg".
+func RenditionFileName(fileName string, rendition string) string {
+	if fileName == "" {
+		return ""
+	}
+
+	ext := filepath.Ext(fileName)
+	return strings.TrimSuffix(fileName, ext) + "_" + rendition + ext
+}
+
+func encodeRenditions(img image.Image, fileName string, contentType string) (res []EncodedImage, err error) {
+	for _, rendition := range ImageRenditions {
+		encoded, err := encodeImage(resizeImage(img, rendition.MaxSize), contentType)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, EncodedImage{
+			FileName:    RenditionFileName(fileName, rendition.Name),
+			ContentType: contentType,
+			Data:        encoded,
+		})
+	}
+
+	return res, nil
+}
+
+func decodeImage(data []byte) (image.Image, string, error) {
+	format := http.DetectContentType(data)
+
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decode func(io.Reader) (image.Image, error)
+	switch format {
+	case "image/jpeg":
+		decodeConfig, decode = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decode = png.DecodeConfig, png.Decode
+	case "image/webp":
+		decodeConfig, decode = webp.DecodeConfig, webp.Decode
+	default:
+		return nil, "", ErrUnsupportedImage
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+
+	if config.Width*config.Height > maxImagePixels {
+		return nil, "", fmt.Errorf("%w, maksimal %d piksel", ErrImageTooLarge, maxImagePixels)
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+
+	// EXIF is dropped when the image is re-encoded, so apply its
+	// orientation to the pixels first.
+	if format == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	return img, format, nil
+}
+
+func encodeImage(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	if contentType == "image/png" {
+		err := png.Encode(&buf, img)
+		return buf.Bytes(), err
+	}
+
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	return buf.Bytes(), err
+}
+
+func resizeImage(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxSize/width)
+		width = maxSize
+	} else {
+		width = max(1, width*maxSize/height)
+		height = maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+	return dst
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+
+	return true
+}
+
+// jpegOrientation returns the EXIF orientation tag (1-8) of a JPEG file, or
+// 1 when there is none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+
+		marker := data[i+1]
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+
+	return 1
+}
+
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			}
+			dst.Set(dx, dy, color.RGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)))
+		}
+	}
+
+	return dst
+}
@@ -9,14 +9,18 @@ import (
 
 var signatureKEY []byte
 
+// DataClaims identify the user only, roles and permissions are looked up on
+// every request so changes apply to tokens already issued.
 type DataClaims struct {
-	ID      string `json:"id"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
+	ID    string `json:"id"`
+	Email string `json:"email"`
 }
 
 type Claims struct {
 	DataClaims
+	// IssuedAtMilli is the issue time in Unix milliseconds, iat only has
+	// whole seconds.
+	IssuedAtMilli int64 `json:"iat_ms"`
 	jwt.StandardClaims
 }
 
@@ -24,15 +28,31 @@ func InitJWT(key string) {
 	signatureKEY = []byte(key)
 }
 
-func NewToken(params DataClaims) *Claims {
+// NewToken builds the claims of an access token valid for ttl. The issue
+// time lets a token be rejected once its user revoked earlier tokens.
+func NewToken(params DataClaims, ttl time.Duration) *Claims {
+	now := time.Now()
 	return &Claims{
-		DataClaims: params,
+		DataClaims:    params,
+		IssuedAtMilli: now.UnixMilli(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
 		},
 	}
 }
 
+// TokenIssuedAt returns the issue time of decoded claims, to the millisecond
+// for tokens carrying iat_ms and to the second for older ones.
+func TokenIssuedAt(claims jwt.MapClaims) time.Time {
+	if issuedAtMilli, ok := claims["iat_ms"].(float64); ok {
+		return time.UnixMilli(int64(issuedAtMilli))
+	}
+
+	issuedAt, _ := claims["iat"].(float64)
+	return time.Unix(int64(issuedAt), 0)
+}
+
 func (c *Claims) Create() (string, error) {
 	tokens := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
 	signedStr, err := tokens.SignedString(signatureKEY)
@@ -1,8 +1,53 @@
 package utils
 
+import (
+	"strings"
+	"unicode"
+)
+
 func NilIfZeroUint(input *uint) *uint {
 	if input != nil && *input == 0 {
 		return nil
 	}
 	return input
 }
+
+var searchStopWords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true,
+	"untuk": true, "dengan": true, "ini": true, "itu": true, "atau": true,
+	"pada": true, "dalam": true, "adalah": true, "juga": true, "akan": true,
+	"ada": true, "bisa": true, "buat": true, "oleh": true, "sebagai": true,
+	"para": true, "serta": true, "sudah": true, "telah": true, "tidak": true,
+}
+
+// NormalizeSearchQuery lowercases the query, strips punctuation and drops
+// common Indonesian stop words, returning the remaining terms.
+func NormalizeSearchQuery(query string) []string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, query)
+
+	var terms []string
+	for _, term := range strings.Fields(cleaned) {
+		if searchStopWords[term] {
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	return terms
+}
+
+// BuildFulltextQuery turns normalized terms into a MySQL boolean mode
+// expression where every term is required and matched by prefix.
+func BuildFulltextQuery(terms []string) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		parts = append(parts, "+"+term+"*")
+	}
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GenerateOTP returns a random numeric code of the given number of digits.
+func GenerateOTP(digits int) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < digits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+
+	return sb.String(), nil
+}
+
+// HashOTP hashes a one time password with bcrypt. Unlike opaque tokens the
+// codes are short enough to brute force from a plain SHA-256.
+func HashOTP(code string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hashed), nil
+}
+
+func CheckOTPHash(code, hashedCode string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedCode), []byte(code)) == nil
+}
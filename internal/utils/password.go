@@ -2,6 +2,9 @@ package utils
 
 import "golang.org/x/crypto/bcrypt"
 
+// dummyPasswordHash has the cost of HashPassword, see EqualizePasswordCheck.
+const dummyPasswordHash = "$2a$14$QBvvHnrsieSfPvkQXr7OwO.118g7D3nKd/1lBuFPjWo.WTGPxkkki"
+
 func HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	if err != nil {
@@ -15,3 +18,9 @@ func CheckPasswordHash(password, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+// EqualizePasswordCheck takes as long as CheckPasswordHash, for logins of
+// unknown users that would otherwise answer faster than a wrong password.
+func EqualizePasswordCheck(password string) {
+	CheckPasswordHash(password, dummyPasswordHash)
+}
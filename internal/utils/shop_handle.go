@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	ShopHandleMinLength = 3
+	ShopHandleMaxLength = 30
+)
+
+var (
+	ErrShopHandleInvalid  = errors.New("handle harus 3 sampai 30 karakter berupa huruf kecil, angka atau tanda hubung")
+	ErrShopHandleReserved = errors.New("handle tidak dapat digunakan")
+)
+
+var shopHandlePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// shopHandleSuffixRoom is left free by ShopHandleFromName for the numeric
+// suffix UniqueSlug may add.
+const shopHandleSuffixRoom = 4
+
+// reservedShopHandles are words a shop cannot be named after, because they
+// are used by routes or could pass the shop off as the marketplace itself.
+var reservedShopHandles = map[string]bool{
+	"admin": true, "administrator": true, "api": true, "auth": true,
+	"cart": true, "category": true, "checkout": true, "evermos": true,
+	"feed": true, "files": true, "follow": true, "handle": true,
+	"help": true, "login": true, "logout": true, "moderator": true,
+	"my": true, "official": true, "product": true, "produk": true,
+	"register": true, "root": true, "settings": true, "storefront": true,
+	"support": true, "system": true, "toko": true, "trx": true,
+	"user": true,
+}
+
+// ValidateShopHandle checks that handle is URL-safe, within length and not
+// a reserved word.
+func ValidateShopHandle(handle string) error {
+	if len(handle) < ShopHandleMinLength || len(handle) > ShopHandleMaxLength || !shopHandlePattern.MatchString(handle) {
+		return ErrShopHandleInvalid
+	}
+
+	if IsReservedShopHandle(handle) {
+		return ErrShopHandleReserved
+	}
+
+	return nil
+}
+
+func IsReservedShopHandle(handle string) bool {
+	return reservedShopHandles[handle]
+}
+
+// ShopHandleFromName derives a handle for a shop that did not choose one.
+// Reserved or too short slugs get a "toko-" prefix, and long ones are cut
+// so that a numeric suffix still fits in ShopHandleMaxLength.
+func ShopHandleFromName(name string) string {
+	handle := GenerateSlug(name)
+	if handle == "" {
+		handle = "toko-baru"
+	}
+	if len(handle) < ShopHandleMinLength || IsReservedShopHandle(handle) {
+		handle = "toko-" + handle
+	}
+
+	if max := ShopHandleMaxLength - shopHandleSuffixRoom; len(handle) > max {
+		handle = strings.TrimRight(handle[:max], "-")
+	}
+
+	return handle
+}
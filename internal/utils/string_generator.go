@@ -21,3 +21,48 @@ func GenerateShopName(shopName string) string {
 
 	return strings.ReplaceAll(shopName, " ", "")
 }
+
+var slugTransliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ì': "i", 'í': "i",
+	'î': "i", 'ï': "i", 'ñ': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o",
+	'ö': "o", 'ø': "o", 'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y",
+	'ÿ': "y", 'ß': "ss", '&': " dan ",
+}
+
+// GenerateSlug transliterates, lowercases and hyphenates text into a URL-safe
+// slug. It returns an empty string when nothing usable is left.
+func GenerateSlug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if t, ok := slugTransliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			continue
+		}
+
+		b.WriteRune(' ')
+	}
+
+	return strings.Join(strings.Fields(b.String()), "-")
+}
+
+// UniqueSlug returns base, or base with the lowest numeric suffix starting at
+// 2, that does not appear in taken.
+func UniqueSlug(base string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	slug := base
+	for i := 2; used[slug]; i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	return slug
+}